@@ -42,7 +42,14 @@ export async function activate(context: vscode.ExtensionContext) {
           scheme: "untitled",
           language: "vocab",
         },
+        {
+          scheme: "file",
+          language: "markdown",
+        },
       ],
+      synchronize: {
+        configurationSection: "vocab",
+      },
     };
   })();
 
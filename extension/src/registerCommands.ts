@@ -10,6 +10,10 @@ export function registerCommands(client: LanguageClient) {
       it: string[];
       de: string[];
     };
+    production: {
+      it: string[];
+      de: string[];
+    };
   };
 
   disposables.push(
@@ -23,7 +27,11 @@ export function registerCommands(client: LanguageClient) {
         currentDocumentUri: editor.document.uri.toString(),
       })) as CollectResponse;
 
-      await addNewWordSectionUseCase(editor.document, response.words);
+      await addNewWordSectionUseCase(
+        editor.document,
+        response.words,
+        response.production
+      );
     })
   );
 
@@ -38,7 +46,11 @@ export function registerCommands(client: LanguageClient) {
         "vocab/collectAll"
       )) as CollectResponse;
 
-      await addNewWordSectionUseCase(editor.document, response.words);
+      await addNewWordSectionUseCase(
+        editor.document,
+        response.words,
+        response.production
+      );
     })
   );
 
@@ -5,9 +5,25 @@ export async function addNewWordSectionUseCase(
   words: {
     it: string[];
     de: string[];
-  }
+  },
+  production: {
+    it: string[];
+    de: string[];
+  } = { it: [], de: [] }
 ) {
-  if (words.it.length + words.de.length === 0) {
+  // words due both ways are reviewed both ways on the main line
+  const productionOnly = {
+    it: production.it.filter((word) => !words.it.includes(word)),
+    de: production.de.filter((word) => !words.de.includes(word)),
+  };
+
+  if (
+    words.it.length +
+      words.de.length +
+      productionOnly.it.length +
+      productionOnly.de.length ===
+    0
+  ) {
     vscode.window.showInformationMessage("Nothing to review for now!");
     return;
   }
@@ -30,6 +46,14 @@ export async function addNewWordSectionUseCase(
       contents.push(`>> (de) ${words.de.join(", ")}`);
     }
 
+    if (productionOnly.it.length > 0) {
+      contents.push(`>> (it) ${productionOnly.it.join(", ")} | production`);
+    }
+
+    if (productionOnly.de.length > 0) {
+      contents.push(`>> (de) ${productionOnly.de.join(", ")} | production`);
+    }
+
     const joined = ["\n", ...contents.join("\n")].join("");
     return joined;
   })();
@@ -42,6 +66,8 @@ export async function addNewWordSectionUseCase(
   await vscode.workspace.applyEdit(edit);
 
   vscode.window.showInformationMessage(
-    `Added ${words.it.length} it words and ${words.de.length} words`
+    `Added ${words.it.length + productionOnly.it.length} it words and ${
+      words.de.length + productionOnly.de.length
+    } words`
   );
 }
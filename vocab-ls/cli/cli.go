@@ -0,0 +1,94 @@
+package cli
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"maps"
+	"path/filepath"
+	"slices"
+	"strings"
+	"vocab/harvester"
+	"vocab/lib"
+	lsproto "vocab/lsp"
+	"vocab/vocabulary/forest"
+)
+
+// A command run from the terminal instead of serving a language client.
+type command struct {
+	usage string
+	run   func(ctx context.Context, flags *flag.FlagSet, args []string, stdout io.Writer) error
+}
+
+var commands = map[string]command{
+	"export": {
+		usage: "export [-format json|csv|anki] [-o file] [folder...]",
+		run:   exportHistory,
+	},
+	"import": {
+		usage: "import [-word col] [-date col] [-lang-column col | -lang code] [-grade col | -ease col] [-date-layout layout] [-header] [-date-format format] [file]",
+		run:   importRows,
+	},
+	"forecast": {
+		usage: "forecast [-days n] [-as-of yyyy-mm-dd] [folder...]",
+		run:   forecast,
+	},
+	"cloze": {
+		usage: "cloze [-format tsv|json] [-as-of yyyy-mm-dd] [folder...]",
+		run:   cloze,
+	},
+}
+
+// Whether name is a command. Anything else, like the --stdio flag of language clients, starts the
+// language server.
+func IsCommand(name string) bool {
+	_, exists := commands[name]
+	return exists
+}
+
+// Run the command named by args[0] and return the exit code.
+func Run(ctx context.Context, args []string, stdout io.Writer, stderr io.Writer) int {
+	cmd, exists := commands[args[0]]
+	if !exists {
+		names := slices.Sorted(maps.Keys(commands))
+		fmt.Fprintf(stderr, "unknown command %s, expect one of %s\n", args[0], strings.Join(names, ", "))
+		return 2
+	}
+
+	flags := flag.NewFlagSet(args[0], flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() {
+		fmt.Fprintf(stderr, "usage: vocab-ls %s\n", cmd.usage)
+		flags.PrintDefaults()
+	}
+
+	if err := cmd.run(ctx, flags, args[1:], stdout); err != nil {
+		if err != flag.ErrHelp {
+			fmt.Fprintln(stderr, err)
+		}
+		return 1
+	}
+	return 0
+}
+
+// Plant every vocab file under folders, or under the working directory if there are none.
+func plantFolders(f *forest.Forest, folders []string) error {
+	if len(folders) == 0 {
+		folders = []string{"."}
+	}
+	workspace := harvester.NewWorkspace(f, nil, lib.NewLogger(io.Discard))
+	params := &lsproto.InitializeParams{}
+	for _, folder := range folders {
+		absolute, err := filepath.Abs(folder)
+		if err != nil {
+			return err
+		}
+		params.WorkspaceFolders = append(params.WorkspaceFolders, lsproto.WorkspaceFolder{
+			Uri:  harvester.PathToLspUri(absolute),
+			Name: filepath.Base(absolute),
+		})
+	}
+	workspace.Initialize(params)
+	return nil
+}
@@ -0,0 +1,67 @@
+package cli
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	test "vocab/vocab_testing"
+)
+
+func TestIsCommand_ShouldLeaveLanguageServerFlagsAlone(t *testing.T) {
+	test.Expect(t, true, IsCommand("forecast"))
+	test.Expect(t, false, IsCommand("--stdio"))
+}
+
+func TestForecast_ShouldPrintOneRowPerDay(t *testing.T) {
+	journal := t.TempDir()
+	os.WriteFile(filepath.Join(journal, "it.vocab"), []byte("01/06/2025\n> (it) casa, mostrare"), 0o644)
+
+	var stdout, stderr bytes.Buffer
+	code := Run(t.Context(), []string{"forecast", "-days", "3", "-as-of", "2025-06-09", journal}, &stdout, &stderr)
+
+	test.Expect(t, 0, code)
+	lines := strings.Split(strings.TrimSpace(stdout.String()), "\n")
+	test.Expect(t, 4, len(lines))
+	test.Expect(t, true, strings.Contains(lines[0], "if all graded 4"))
+	test.Expect(t, "2025-06-09", strings.Fields(lines[1])[0])
+	test.Expect(t, "2", strings.Fields(lines[1])[1])
+}
+
+func TestRun_ShouldFailOnBadFlags(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	test.Expect(t, 1, Run(t.Context(), []string{"forecast", "-as-of", "friday"}, &stdout, &stderr))
+	test.Expect(t, true, strings.Contains(stderr.String(), "yyyy-mm-dd"))
+}
+
+func TestExport_ShouldWriteTheFileGiven(t *testing.T) {
+	journal := t.TempDir()
+	os.WriteFile(filepath.Join(journal, "it.vocab"), []byte("01/06/2025\n> (it) casa, mostrare"), 0o644)
+	output := filepath.Join(t.TempDir(), "history.csv")
+
+	var stdout, stderr bytes.Buffer
+	code := Run(t.Context(), []string{"export", "-format", "csv", "-o", output, journal}, &stdout, &stderr)
+
+	test.Expect(t, 0, code)
+	written, _ := os.ReadFile(output)
+	lines := strings.Split(strings.TrimSpace(string(written)), "\n")
+	test.Expect(t, 3, len(lines))
+	test.Expect(t, true, strings.Contains(lines[1], filepath.Join(journal, "it.vocab")))
+}
+
+func TestCloze_ShouldPrintOneCardPerLine(t *testing.T) {
+	journal := t.TempDir()
+	os.WriteFile(filepath.Join(journal, "it.vocab"), []byte("01/06/2025\n> (it) casa, mostrare\nLa casa è grande."), 0o644)
+
+	var stdout, stderr bytes.Buffer
+	code := Run(t.Context(), []string{"cloze", "-as-of", "2025-06-09", journal}, &stdout, &stderr)
+
+	test.Expect(t, 0, code)
+	lines := strings.Split(strings.TrimSpace(stdout.String()), "\n")
+	test.Expect(t, 1, len(lines))
+	fields := strings.Split(lines[0], "\t")
+	test.Expect(t, "La ____ è grande.", fields[0])
+	test.Expect(t, "casa", fields[1])
+	test.Expect(t, filepath.Join(journal, "it.vocab")+":3", fields[4])
+}
@@ -0,0 +1,59 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+	"vocab/lib"
+	"vocab/vocabulary/forest"
+)
+
+// Print a cloze of every word due, made of the latest utterance that uses it, for every vocab file
+// under the given folders.
+func cloze(ctx context.Context, flags *flag.FlagSet, args []string, stdout io.Writer) error {
+	format := flags.String("format", "tsv", "tsv, one sentence, answer, word, language and place per line, or json")
+	asOf := flags.String("as-of", "", "make clozes of what is due on this yyyy-mm-dd date instead of today")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *format != "tsv" && *format != "json" {
+		return fmt.Errorf("unknown format %s, expect tsv or json", *format)
+	}
+
+	f := forest.NewForest(ctx, func(any) {})
+	day := f.Snapshot().Today()
+	if *asOf != "" {
+		parsed, err := time.Parse(time.DateOnly, *asOf)
+		if err != nil {
+			return fmt.Errorf("expect -as-of to be a yyyy-mm-dd date: %w", err)
+		}
+		day = parsed
+	}
+
+	if err := plantFolders(f, flags.Args()); err != nil {
+		return err
+	}
+
+	clozes := f.Clozes(day)
+	if *format == "json" {
+		encoder := json.NewEncoder(stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(clozes)
+	}
+	field := strings.NewReplacer("\t", " ", "\n", " ")
+	for _, cloze := range clozes {
+		place := fmt.Sprintf("%s:%d", lib.UriToPath(cloze.Location.Uri), cloze.Location.Range.Start.Line+1)
+		fmt.Fprintf(stdout, "%s\t%s\t%s\t%s\t%s\n",
+			field.Replace(cloze.Sentence),
+			field.Replace(cloze.Answer),
+			field.Replace(cloze.Word),
+			cloze.Lang,
+			place,
+		)
+	}
+	return nil
+}
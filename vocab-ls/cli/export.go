@@ -0,0 +1,43 @@
+package cli
+
+import (
+	"context"
+	"flag"
+	"io"
+	"os"
+	"vocab/export"
+	"vocab/lib"
+	"vocab/vocabulary/forest"
+)
+
+// Write one row for every word written in every vocab file under the given folders.
+func exportHistory(ctx context.Context, flags *flag.FlagSet, args []string, stdout io.Writer) error {
+	formatName := flags.String("format", "json", "json, csv or anki")
+	output := flags.String("o", "", "write to this file instead of stdout")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	format, err := export.ParseFormat(*formatName)
+	if err != nil {
+		return err
+	}
+
+	f := forest.NewForest(ctx, func(any) {})
+	if err := plantFolders(f, flags.Args()); err != nil {
+		return err
+	}
+	rows := export.NewRows(f.History(), lib.UriToPath)
+
+	if *output == "" {
+		return export.Write(stdout, format, rows)
+	}
+	file, err := os.Create(*output)
+	if err != nil {
+		return err
+	}
+	if err := export.Write(file, format, rows); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
@@ -0,0 +1,66 @@
+package cli
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"text/tabwriter"
+	"time"
+	"vocab/vocabulary/forest"
+)
+
+// Print how many words fall due on each of the coming days, for every vocab file under the given
+// folders.
+func forecast(ctx context.Context, flags *flag.FlagSet, args []string, stdout io.Writer) error {
+	days := flags.Int("days", 14, "how many days to forecast")
+	asOf := flags.String("as-of", "", "forecast from this yyyy-mm-dd date instead of today")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	f := forest.NewForest(ctx, func(any) {})
+	day := f.Snapshot().Today()
+	if *asOf != "" {
+		parsed, err := time.Parse(time.DateOnly, *asOf)
+		if err != nil {
+			return fmt.Errorf("expect -as-of to be a yyyy-mm-dd date: %w", err)
+		}
+		day = parsed
+	}
+
+	if err := plantFolders(f, flags.Args()); err != nil {
+		return err
+	}
+
+	printForecast(stdout, f.Forecast(day, *days))
+	return nil
+}
+
+func printForecast(out io.Writer, forecast forest.Forecast) {
+	languages := map[string]struct{}{}
+	for _, day := range forecast.Days {
+		for lang := range day.Languages {
+			languages[lang] = struct{}{}
+		}
+	}
+	langs := slices.Sorted(maps.Keys(languages))
+
+	table := tabwriter.NewWriter(out, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprint(table, "date\tdue\tnew\treviews\t")
+	for _, lang := range langs {
+		fmt.Fprintf(table, "%s\t", lang)
+	}
+	fmt.Fprintf(table, "if all graded %d\t\n", forest.ProjectedGrade)
+
+	for i, day := range forecast.Days {
+		fmt.Fprintf(table, "%s\t%d\t%d\t%d\t", day.Date.Format(time.DateOnly), day.Total.Due, day.Total.New, day.Total.Reviews)
+		for _, lang := range langs {
+			fmt.Fprintf(table, "%d\t", day.Languages[lang].Due)
+		}
+		fmt.Fprintf(table, "%d\t\n", forecast.Projected[i].Total.Due)
+	}
+	table.Flush()
+}
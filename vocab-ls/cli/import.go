@@ -0,0 +1,51 @@
+package cli
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"vocab/config"
+	"vocab/importer"
+)
+
+// Print the rows of a CSV or TSV file as vocab sections, to be appended to a vocab file.
+func importRows(ctx context.Context, flags *flag.FlagSet, args []string, stdout io.Writer) error {
+	mapping := importer.Mapping{}
+	flags.StringVar(&mapping.Word, "word", "word", "column of the word, by header name or number starting at 1")
+	flags.StringVar(&mapping.Date, "date", "date", "column of the date")
+	flags.StringVar(&mapping.Lang, "lang-column", "", "column of the language code")
+	flags.StringVar(&mapping.Grade, "grade", "", "column of the 0 to 5 grade")
+	flags.StringVar(&mapping.Ease, "ease", "", "column of the 1 to 4 ease of an Anki review log, instead of -grade")
+	flags.StringVar(&mapping.TwoWay, "two-way", "", "column telling whether the word is graded in both directions, true or false")
+	flags.StringVar(&mapping.ProductionGrade, "production-grade", "", "column of the 0 to 5 grade of producing a word graded in both directions")
+	flags.StringVar(&mapping.DefaultLang, "lang", "", "language code of the rows without a language column")
+	flags.StringVar(&mapping.DateLayout, "date-layout", "", "go layout of the dates, or unixms for Anki review log ids (default yyyy-mm-dd)")
+	flags.BoolVar(&mapping.Header, "header", false, "skip the first row when columns are numbers")
+	dateFormat := flags.String("date-format", config.Default().DateFormat, "date format of the sections written")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if _, known := config.DateFormats[*dateFormat]; !known {
+		return fmt.Errorf("unknown date format %s", *dateFormat)
+	}
+	layout := config.Config{DateFormat: *dateFormat}.WritingDateLayout()
+
+	in := io.Reader(os.Stdin)
+	if flags.NArg() > 0 {
+		file, err := os.Open(flags.Arg(0))
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		in = file
+	}
+
+	entries, err := importer.Read(in, mapping)
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(stdout, importer.Sections(entries, layout))
+	return err
+}
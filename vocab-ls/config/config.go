@@ -0,0 +1,392 @@
+package config
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+	lsproto "vocab/lsp"
+	"vocab/super_memo"
+	"vocab/syntax"
+	"vocab/vocabulary/parser"
+)
+
+// File name of the workspace configuration, looked up at the root of every workspace folder.
+const FileName = ".vocabrc"
+
+// The settings section requested from the client through workspace/configuration.
+const Section = "vocab"
+
+// Every setting of the language server.
+//
+// Values start from Default, then each .vocabrc, then the client settings, each layer only
+// overriding the keys it sets.
+type Config struct {
+	Diagnostics Diagnostics
+	// One of the keys of DateFormats.
+	DateFormat string
+	// File extensions, without the dot, that are planted into the forest.
+	Extensions []string
+	Scheduler  Scheduler
+	// Only read from the .vocabrc of a folder: keep the schedule of that folder to itself.
+	IndependentSchedule bool
+	// Map of language code and the path of its dictionary. Paths written in a .vocabrc are relative
+	// to it.
+	Dictionaries map[string]string
+	// Map of language code and the path of its lemma list, relative like Dictionaries.
+	Lemmas map[string]string
+	// Codes of the languages whose every word is scheduled apart for recognizing and producing it,
+	// as if graded word(4/4).
+	TwoWay []string
+}
+
+type Diagnostics struct {
+	// Words due in this many days or fewer are errors.
+	ErrorWithinDays float64
+	// Words due in less than this many days are hints, the rest are information.
+	HintWithinDays float64
+	// For clients that cannot pull diagnostics, wait for this long after the last change before
+	// pushing them.
+	DebounceMilliseconds float64
+	// Words that lapsed this many times are leeches, 0 turns leech detection off.
+	LeechThreshold float64
+}
+
+type Scheduler struct {
+	InitialEasinessFactor float64
+	MinimumEasinessFactor float64
+	FirstInterval         float64
+	SecondInterval        float64
+	// Written like 10m or 1h, see super_memo.Parameters
+	LearningSteps []time.Duration
+}
+
+func (s Scheduler) Equal(other Scheduler) bool {
+	return s.InitialEasinessFactor == other.InitialEasinessFactor &&
+		s.MinimumEasinessFactor == other.MinimumEasinessFactor &&
+		s.FirstInterval == other.FirstInterval &&
+		s.SecondInterval == other.SecondInterval &&
+		slices.Equal(s.LearningSteps, other.LearningSteps)
+}
+
+// Date formats understood by the scanner, mapped to their go layout.
+var DateFormats = map[string]string{
+	"dd/mm/yyyy": "02/01/2006",
+	"mm/dd/yyyy": "01/02/2006",
+	"dd.mm.yyyy": "02.01.2006",
+	"yyyy-mm-dd": "2006-01-02",
+	// any of the above, dd/mm/yyyy or mm/dd/yyyy depending on the other dates of the file
+	"auto": syntax.AutoDateLayout,
+}
+
+func Default() Config {
+	return Config{
+		Diagnostics: Diagnostics{
+			ErrorWithinDays:      1,
+			HintWithinDays:       3,
+			DebounceMilliseconds: 300,
+			LeechThreshold:       8,
+		},
+		DateFormat: "dd/mm/yyyy",
+		Extensions: []string{"vocab", "md"},
+		Scheduler: Scheduler{
+			InitialEasinessFactor: super_memo.DefaultParameters.InitialEasinessFactor,
+			MinimumEasinessFactor: super_memo.DefaultParameters.MinimumEasinessFactor,
+			FirstInterval:         super_memo.DefaultParameters.FirstInterval,
+			SecondInterval:        super_memo.DefaultParameters.SecondInterval,
+		},
+	}
+}
+
+func (c Config) DateLayout() string {
+	return DateFormats[c.DateFormat]
+}
+
+// The layout new dates are written with.
+func (c Config) WritingDateLayout() string {
+	if c.DateFormat == "auto" {
+		return syntax.DateLayout
+	}
+	return c.DateLayout()
+}
+
+func (c Config) Debounce() time.Duration {
+	return time.Duration(c.Diagnostics.DebounceMilliseconds * float64(time.Millisecond))
+}
+
+func (c Config) IsLeech(lapses int) bool {
+	return c.Diagnostics.LeechThreshold > 0 && float64(lapses) >= c.Diagnostics.LeechThreshold
+}
+
+func (c Config) Parameters() super_memo.Parameters {
+	return super_memo.Parameters{
+		InitialEasinessFactor: c.Scheduler.InitialEasinessFactor,
+		MinimumEasinessFactor: c.Scheduler.MinimumEasinessFactor,
+		FirstInterval:         c.Scheduler.FirstInterval,
+		SecondInterval:        c.Scheduler.SecondInterval,
+		LearningSteps:         c.Scheduler.LearningSteps,
+	}
+}
+
+// Whether every word of lang is scheduled in both directions.
+func (c Config) IsTwoWay(lang parser.Language) bool {
+	return slices.Contains(c.TwoWay, lang.Code())
+}
+
+func (c Config) IsPlantable(fileName string) bool {
+	chunks := strings.Split(fileName, ".")
+	if len(chunks) < 2 {
+		return false
+	}
+	return slices.Contains(c.Extensions, chunks[len(chunks)-1])
+}
+
+func (c Config) Equal(other Config) bool {
+	return c.Diagnostics == other.Diagnostics &&
+		c.DateFormat == other.DateFormat &&
+		slices.Equal(c.Extensions, other.Extensions) &&
+		c.Scheduler.Equal(other.Scheduler) &&
+		c.IndependentSchedule == other.IndependentSchedule &&
+		maps.Equal(c.Dictionaries, other.Dictionaries) &&
+		maps.Equal(c.Lemmas, other.Lemmas) &&
+		slices.Equal(c.TwoWay, other.TwoWay)
+}
+
+// Merge sources on top of the defaults, later sources win.
+//
+// Invalid values are reported and ignored, so a typo never takes the whole configuration down.
+// The returned map holds the diagnostics of each source uri, client settings are under "".
+func Resolve(sources ...*Source) (Config, map[string][]lsproto.Diagnostic) {
+	resolved := Default()
+	diags := make(map[string][]lsproto.Diagnostic)
+
+	for _, source := range sources {
+		if source == nil {
+			continue
+		}
+		if _, exists := diags[source.Uri]; !exists {
+			diags[source.Uri] = []lsproto.Diagnostic{}
+		}
+		diags[source.Uri] = append(diags[source.Uri], source.Diagnostics...)
+
+		var problems []problem
+		resolved, problems = resolved.apply(source)
+		for _, p := range problems {
+			diags[source.Uri] = append(diags[source.Uri], source.diagnose(p))
+		}
+	}
+
+	return resolved, diags
+}
+
+type problem struct {
+	// Path of keys leading to the offending value
+	path     []string
+	message  string
+	severity lsproto.DiagnosticsSeverity
+}
+
+func (c Config) apply(source *Source) (Config, []problem) {
+	values := source.Values
+	next := c
+	next.Extensions = slices.Clone(c.Extensions)
+	next.Scheduler.LearningSteps = slices.Clone(c.Scheduler.LearningSteps)
+	next.Dictionaries = maps.Clone(c.Dictionaries)
+	next.Lemmas = maps.Clone(c.Lemmas)
+	next.TwoWay = slices.Clone(c.TwoWay)
+	problems := []problem{}
+
+	report := func(path []string, format string, args ...any) {
+		problems = append(problems, problem{path, fmt.Sprintf(format, args...), lsproto.DiagnosticsSeverityError})
+	}
+	number := func(path []string, value any, target *float64) {
+		switch n := value.(type) {
+		case float64:
+			*target = n
+		case int64:
+			*target = float64(n)
+		default:
+			report(path, "Expect %s to be a number", strings.Join(path, "."))
+		}
+	}
+	table := func(path []string, value any, each func(key string, value any)) {
+		inner, ok := value.(map[string]any)
+		if !ok {
+			report(path, "Expect %s to be a table of settings", strings.Join(path, "."))
+			return
+		}
+		for _, key := range sortedKeys(inner) {
+			each(key, inner[key])
+		}
+	}
+
+	for _, key := range sortedKeys(values) {
+		value := values[key]
+		path := []string{key}
+		switch key {
+		case "diagnostics":
+			table(path, value, func(inner string, value any) {
+				path := []string{key, inner}
+				switch inner {
+				case "errorWithinDays":
+					number(path, value, &next.Diagnostics.ErrorWithinDays)
+				case "hintWithinDays":
+					number(path, value, &next.Diagnostics.HintWithinDays)
+				case "debounceMilliseconds":
+					number(path, value, &next.Diagnostics.DebounceMilliseconds)
+				case "leechThreshold":
+					number(path, value, &next.Diagnostics.LeechThreshold)
+				default:
+					problems = append(problems, unknownKey(path))
+				}
+			})
+		case "scheduler":
+			table(path, value, func(inner string, value any) {
+				path := []string{key, inner}
+				switch inner {
+				case "initialEasinessFactor":
+					number(path, value, &next.Scheduler.InitialEasinessFactor)
+				case "minimumEasinessFactor":
+					number(path, value, &next.Scheduler.MinimumEasinessFactor)
+				case "firstInterval":
+					number(path, value, &next.Scheduler.FirstInterval)
+				case "secondInterval":
+					number(path, value, &next.Scheduler.SecondInterval)
+				case "learningSteps":
+					items, ok := value.([]any)
+					if !ok {
+						report(path, "Expect scheduler.learningSteps to be a list of durations like \"10m\" or \"1h\"")
+						return
+					}
+					steps := []time.Duration{}
+					for _, item := range items {
+						text, _ := item.(string)
+						step, err := time.ParseDuration(text)
+						if err != nil || step <= 0 {
+							report(path, "Expect scheduler.learningSteps to be a list of durations like \"10m\" or \"1h\"")
+							return
+						}
+						steps = append(steps, step)
+					}
+					next.Scheduler.LearningSteps = steps
+				default:
+					problems = append(problems, unknownKey(path))
+				}
+			})
+		case "dateFormat":
+			format, ok := value.(string)
+			if _, known := DateFormats[format]; !ok || !known {
+				report(path, "Expect dateFormat to be one of %s", strings.Join(sortedKeys(DateFormats), ", "))
+				continue
+			}
+			next.DateFormat = format
+		case "extensions":
+			items, ok := value.([]any)
+			if !ok || len(items) == 0 {
+				report(path, "Expect extensions to be a non-empty list of file extensions")
+				continue
+			}
+			extensions := []string{}
+			for _, item := range items {
+				extension, ok := item.(string)
+				extension = strings.TrimPrefix(extension, ".")
+				if !ok || extension == "" {
+					report(path, "Expect extensions to be a non-empty list of file extensions")
+					extensions = nil
+					break
+				}
+				extensions = append(extensions, extension)
+			}
+			if extensions != nil {
+				next.Extensions = extensions
+			}
+		case "dictionaries", "lemmas":
+			paths, kind := &next.Dictionaries, "dictionary"
+			if key == "lemmas" {
+				paths, kind = &next.Lemmas, "lemma list"
+			}
+			table(path, value, func(code string, value any) {
+				path := []string{key, code}
+				if parser.LanguageOf(code) == parser.Unrecognized {
+					report(path, "Unrecognized language %s, expect it, fr or de", code)
+					return
+				}
+				file, ok := value.(string)
+				if !ok || file == "" {
+					report(path, "Expect %s.%s to be the path of a %s", key, code, kind)
+					return
+				}
+				if *paths == nil {
+					*paths = make(map[string]string)
+				}
+				(*paths)[code] = source.resolvePath(file)
+			})
+		case "twoWay":
+			items, ok := value.([]any)
+			if !ok {
+				report(path, "Expect twoWay to be a list of language codes")
+				continue
+			}
+			codes := []string{}
+			for _, item := range items {
+				code, _ := item.(string)
+				if parser.LanguageOf(code) == parser.Unrecognized {
+					report(path, "Unrecognized language %v, expect it, fr or de", item)
+					codes = nil
+					break
+				}
+				codes = append(codes, code)
+			}
+			if codes != nil {
+				next.TwoWay = codes
+			}
+		case "independentSchedule":
+			independent, ok := value.(bool)
+			if !ok {
+				report(path, "Expect independentSchedule to be true or false")
+				continue
+			}
+			next.IndependentSchedule = independent
+		default:
+			problems = append(problems, unknownKey(path))
+		}
+	}
+
+	// Values that are only wrong together, the whole group falls back.
+	if next.Diagnostics.ErrorWithinDays > next.Diagnostics.HintWithinDays {
+		report([]string{"diagnostics"}, "diagnostics.errorWithinDays must not be greater than diagnostics.hintWithinDays")
+		next.Diagnostics = c.Diagnostics
+	}
+	if next.Diagnostics.DebounceMilliseconds < 0 {
+		report([]string{"diagnostics", "debounceMilliseconds"}, "diagnostics.debounceMilliseconds must not be negative")
+		next.Diagnostics = c.Diagnostics
+	}
+	if next.Diagnostics.LeechThreshold < 0 {
+		report([]string{"diagnostics", "leechThreshold"}, "diagnostics.leechThreshold must not be negative")
+		next.Diagnostics = c.Diagnostics
+	}
+	if next.Scheduler.MinimumEasinessFactor < 1 || next.Scheduler.InitialEasinessFactor < next.Scheduler.MinimumEasinessFactor {
+		report([]string{"scheduler"}, "scheduler.minimumEasinessFactor must be at least 1 and not greater than scheduler.initialEasinessFactor")
+		next.Scheduler = c.Scheduler
+	}
+	if next.Scheduler.FirstInterval <= 0 || next.Scheduler.SecondInterval <= 0 {
+		report([]string{"scheduler"}, "scheduler.firstInterval and scheduler.secondInterval must be greater than 0")
+		next.Scheduler = c.Scheduler
+	}
+
+	return next, problems
+}
+
+func unknownKey(path []string) problem {
+	return problem{path, fmt.Sprintf("Unknown setting %s", strings.Join(path, ".")), lsproto.DiagnosticsSeverityWarning}
+}
+
+func sortedKeys[T any](values map[string]T) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	return keys
+}
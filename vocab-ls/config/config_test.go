@@ -0,0 +1,176 @@
+package config
+
+import (
+	"testing"
+	"time"
+	lsproto "vocab/lsp"
+	test "vocab/vocab_testing"
+	"vocab/vocabulary/parser"
+)
+
+func TestResolve_WithoutSources_ShouldBeDefault(t *testing.T) {
+	resolved, diags := Resolve()
+
+	test.Expect(t, true, resolved.Equal(Default()))
+	test.Expect(t, 0, len(diags))
+	test.Expect(t, "02/01/2006", resolved.DateLayout())
+}
+
+func TestResolve_JsonFile_ShouldOnlyOverrideGivenKeys(t *testing.T) {
+	source := ParseFile("file:///a/.vocabrc", `{
+		"diagnostics": { "errorWithinDays": 0 },
+		"dateFormat": "mm/dd/yyyy",
+		"extensions": ["vocab", ".md"]
+	}`)
+
+	resolved, diags := Resolve(source)
+
+	test.Expect(t, 0, len(diags["file:///a/.vocabrc"]))
+	test.Expect(t, 0.0, resolved.Diagnostics.ErrorWithinDays)
+	test.Expect(t, Default().Diagnostics.HintWithinDays, resolved.Diagnostics.HintWithinDays)
+	test.Expect(t, "01/02/2006", resolved.DateLayout())
+	test.Expect(t, 2, len(resolved.Extensions))
+	test.Expect(t, "md", resolved.Extensions[1])
+	test.Expect(t, true, resolved.IsPlantable("journal.md"))
+	test.Expect(t, false, resolved.IsPlantable("journal.txt"))
+}
+
+func TestResolve_TomlFile(t *testing.T) {
+	source := ParseFile("file:///a/.vocabrc", `
+# thresholds
+independentSchedule = true
+
+[diagnostics]
+hintWithinDays = 7
+
+[scheduler]
+secondInterval = 4.5
+`)
+
+	resolved, diags := Resolve(source)
+
+	test.Expect(t, 0, len(diags["file:///a/.vocabrc"]))
+	test.Expect(t, true, resolved.IndependentSchedule)
+	test.Expect(t, 7.0, resolved.Diagnostics.HintWithinDays)
+	test.Expect(t, 4.5, resolved.Parameters().SecondInterval)
+	test.Expect(t, Default().Scheduler.FirstInterval, resolved.Parameters().FirstInterval)
+}
+
+func TestResolve_LearningSteps(t *testing.T) {
+	source := ParseFile("file:///a/.vocabrc", `
+[scheduler]
+learningSteps = ["10m", "1h"]
+`)
+
+	resolved, diags := Resolve(source)
+
+	test.Expect(t, 0, len(diags["file:///a/.vocabrc"]))
+	test.Expect(t, 2, len(resolved.Parameters().LearningSteps))
+	test.Expect(t, time.Hour, resolved.Parameters().LearningSteps[1])
+	test.Expect(t, false, resolved.Equal(Default()))
+
+	invalid := ParseFile("file:///a/.vocabrc", `{"scheduler": {"learningSteps": ["10m", "soon"]}}`)
+	resolved, diags = Resolve(invalid)
+
+	test.Expect(t, 1, len(diags["file:///a/.vocabrc"]))
+	test.Expect(t, 0, len(resolved.Parameters().LearningSteps))
+}
+
+func TestResolve_Dictionaries_ShouldBeRelativeToTheVocabrc(t *testing.T) {
+	source := ParseFile("file:///a/.vocabrc", `
+[dictionaries]
+it = "dictionaries/it.tsv"
+de = "/usr/share/de.ifo"
+xx = "xx.tsv"
+
+[lemmas]
+it = "lemmas/it.txt"
+`)
+
+	resolved, diags := Resolve(source, FromSettings(map[string]any{"dictionaries": map[string]any{"fr": "fr.tsv"}}))
+
+	test.Expect(t, 1, len(diags["file:///a/.vocabrc"]))
+	test.Expect(t, 3, len(resolved.Dictionaries))
+	test.Expect(t, "/a/dictionaries/it.tsv", resolved.Dictionaries["it"])
+	test.Expect(t, "/usr/share/de.ifo", resolved.Dictionaries["de"])
+	test.Expect(t, "fr.tsv", resolved.Dictionaries["fr"])
+	test.Expect(t, 0, len(Default().Dictionaries))
+	test.Expect(t, "/a/lemmas/it.txt", resolved.Lemmas["it"])
+}
+
+func TestResolve_ClientSettingsShouldWinOverFile(t *testing.T) {
+	file := ParseFile("file:///a/.vocabrc", `{"diagnostics": {"hintWithinDays": 7}}`)
+	settings := FromSettings(map[string]any{"diagnostics": map[string]any{"hintWithinDays": 5.0}})
+
+	resolved, _ := Resolve(file, settings)
+
+	test.Expect(t, 5.0, resolved.Diagnostics.HintWithinDays)
+}
+
+func TestResolve_InvalidValues_ShouldBeReportedWhereTheyAreAndIgnored(t *testing.T) {
+	source := ParseFile("file:///a/.vocabrc", `{
+	"dateFormat": "yyyy/dd/mm",
+	"diagnostics": {
+		"errorWithinDays": "soon"
+	},
+	"colour": "red"
+}`)
+
+	resolved, diags := Resolve(source)
+
+	test.Expect(t, Default().DateFormat, resolved.DateFormat)
+	test.Expect(t, Default().Diagnostics.ErrorWithinDays, resolved.Diagnostics.ErrorWithinDays)
+
+	fileDiags := diags["file:///a/.vocabrc"]
+	test.Expect(t, 3, len(fileDiags))
+	// keys are visited in order
+	test.Expect(t, 5, fileDiags[0].Range.Start.Line)
+	test.Expect(t, lsproto.DiagnosticsSeverityWarning, fileDiags[0].Severity)
+	test.Expect(t, 1, fileDiags[1].Range.Start.Line)
+	test.Expect(t, 1, fileDiags[1].Range.Start.Character)
+	test.Expect(t, 13, fileDiags[1].Range.End.Character)
+	test.Expect(t, 3, fileDiags[2].Range.Start.Line)
+	test.Expect(t, lsproto.DiagnosticsSeverityError, fileDiags[2].Severity)
+}
+
+func TestResolve_ValuesThatOnlyConflictTogether_ShouldFallBackAsAGroup(t *testing.T) {
+	source := ParseFile("file:///a/.vocabrc", `
+[diagnostics]
+errorWithinDays = 5
+hintWithinDays = 2
+`)
+
+	resolved, diags := Resolve(source)
+
+	test.Expect(t, true, resolved.Diagnostics == Default().Diagnostics)
+	test.Expect(t, 1, len(diags["file:///a/.vocabrc"]))
+	test.Expect(t, 1, diags["file:///a/.vocabrc"][0].Range.Start.Line)
+}
+
+func TestParseFile_SyntaxErrorShouldBeADiagnostic(t *testing.T) {
+	jsonSource := ParseFile("file:///a/.vocabrc", "{\n  \"dateFormat\": \n}")
+	test.Expect(t, 1, len(jsonSource.Diagnostics))
+	test.Expect(t, 2, jsonSource.Diagnostics[0].Range.Start.Line)
+	test.Expect(t, 0, len(jsonSource.Values))
+
+	tomlSource := ParseFile("file:///a/.vocabrc", "[diagnostics]\nerrorWithinDays")
+	test.Expect(t, 1, len(tomlSource.Diagnostics))
+	test.Expect(t, 1, tomlSource.Diagnostics[0].Range.Start.Line)
+
+	_, diags := Resolve(tomlSource)
+	test.Expect(t, 1, len(diags["file:///a/.vocabrc"]))
+}
+
+func TestResolve_TwoWay(t *testing.T) {
+	resolved, diags := Resolve(ParseFile("file:///a/.vocabrc", `twoWay = ["de"]`))
+
+	test.Expect(t, 0, len(diags["file:///a/.vocabrc"]))
+	test.Expect(t, true, resolved.IsTwoWay(parser.Deutsch))
+	test.Expect(t, false, resolved.IsTwoWay(parser.Italiano))
+	test.Expect(t, false, resolved.Equal(Default()))
+
+	resolved, diags = Resolve(ParseFile("file:///a/.vocabrc", `twoWay = ["de", "en"]`))
+
+	test.Expect(t, 1, len(diags["file:///a/.vocabrc"]))
+	test.Expect(t, false, resolved.IsTwoWay(parser.Deutsch))
+}
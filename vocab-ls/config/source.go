@@ -0,0 +1,135 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+	"vocab/lib"
+	lsproto "vocab/lsp"
+)
+
+// One layer of settings: a .vocabrc file or the settings sent by the client.
+type Source struct {
+	// Uri of the file the settings are read from, empty for client settings.
+	Uri    string
+	Text   string
+	Values map[string]any
+	// Syntax errors of the file itself.
+	Diagnostics []lsproto.Diagnostic
+}
+
+// Parse the text of a .vocabrc, either JSON or TOML.
+//
+// A file that fails to parse still returns a source, with no values and the syntax error as
+// diagnostic.
+func ParseFile(uri string, text string) *Source {
+	source := &Source{Uri: uri, Text: text, Values: map[string]any{}, Diagnostics: []lsproto.Diagnostic{}}
+
+	if strings.HasPrefix(strings.TrimSpace(text), "{") {
+		if err := json.Unmarshal([]byte(text), &source.Values); err != nil {
+			source.Values = map[string]any{}
+			line, character := 0, 0
+			var syntaxErr *json.SyntaxError
+			if errors.As(err, &syntaxErr) {
+				line, character = offsetToPosition(text, int(syntaxErr.Offset))
+			}
+			source.Diagnostics = append(source.Diagnostics, *lsproto.MakeDiagnostics(
+				"Invalid JSON: "+err.Error(), line, character, character+1, lsproto.DiagnosticsSeverityError,
+			))
+		}
+		return source
+	}
+
+	values, err := parseToml(text)
+	if err != nil {
+		var tomlErr *tomlError
+		line := 0
+		if errors.As(err, &tomlErr) {
+			line = tomlErr.line
+		}
+		source.Diagnostics = append(source.Diagnostics, *lsproto.MakeDiagnostics(
+			"Invalid TOML: "+err.Error(), line, 0, lineLength(text, line), lsproto.DiagnosticsSeverityError,
+		))
+		return source
+	}
+	source.Values = values
+	return source
+}
+
+// Settings from workspace/configuration or workspace/didChangeConfiguration.
+func FromSettings(values map[string]any) *Source {
+	if values == nil {
+		values = map[string]any{}
+	}
+	return &Source{Values: values}
+}
+
+// Make path absolute against the folder of the file, and ~ against the home directory. Client
+// settings have no folder, other relative paths are kept as they are.
+func (s *Source) resolvePath(path string) string {
+	if home, err := os.UserHomeDir(); err == nil && strings.HasPrefix(path, "~/") {
+		return filepath.Join(home, path[2:])
+	}
+	if s.Uri == "" || filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(filepath.Dir(lib.UriToPath(s.Uri)), path)
+}
+
+func (s *Source) diagnose(p problem) lsproto.Diagnostic {
+	line, start, end := s.locate(p.path)
+	return *lsproto.MakeDiagnostics(p.message, line, start, end, p.severity)
+}
+
+// Find the line of the innermost key of path, for both `"key":` and `key =` notations.
+// Falls back to the start of the file.
+func (s *Source) locate(path []string) (int, int, int) {
+	if len(path) == 0 {
+		return 0, 0, 0
+	}
+	key := path[len(path)-1]
+	for i, line := range strings.Split(s.Text, "\n") {
+		trimmed := strings.TrimLeft(line, " \t")
+		indent := len(line) - len(trimmed)
+		candidates := []string{`"` + key + `"`, key}
+		// dotted toml keys, [diagnostics] errorWithinDays = 1 or diagnostics.errorWithinDays = 1
+		if len(path) > 1 {
+			candidates = append(candidates, strings.Join(path, "."))
+		}
+		for _, candidate := range candidates {
+			at := strings.Index(trimmed, candidate)
+			if at == -1 {
+				continue
+			}
+			rest := strings.TrimLeft(trimmed[at+len(candidate):], " \t\"")
+			if !strings.HasPrefix(rest, ":") && !strings.HasPrefix(rest, "=") && !strings.HasPrefix(rest, "]") {
+				continue
+			}
+			if at > 0 && !strings.ContainsAny(trimmed[:at], "{,[. \t") {
+				continue
+			}
+			start := utf8.RuneCountInString(line[:indent+at])
+			return i, start, start + utf8.RuneCountInString(candidate)
+		}
+	}
+	return 0, 0, 0
+}
+
+func offsetToPosition(text string, offset int) (int, int) {
+	offset = min(offset, len(text))
+	before := text[:offset]
+	line := strings.Count(before, "\n")
+	lastBreak := strings.LastIndex(before, "\n")
+	return line, utf8.RuneCountInString(before[lastBreak+1:])
+}
+
+func lineLength(text string, line int) int {
+	lines := strings.Split(text, "\n")
+	if line >= len(lines) {
+		return 0
+	}
+	return utf8.RuneCountInString(lines[line])
+}
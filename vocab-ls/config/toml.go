@@ -0,0 +1,192 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Just enough TOML for a settings file: comments, [tables], dotted keys and single-line values
+// (strings, numbers, booleans and arrays of those). Numbers are int64 or float64.
+//
+// https://toml.io/en/v1.0.0
+
+type tomlError struct {
+	line    int
+	message string
+}
+
+func (e *tomlError) Error() string {
+	return fmt.Sprintf("line %d: %s", e.line+1, e.message)
+}
+
+func parseToml(text string) (map[string]any, error) {
+	root := map[string]any{}
+	current := root
+
+	for i, raw := range strings.Split(text, "\n") {
+		line := strings.TrimSpace(stripTomlComment(raw))
+		if line == "" {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") {
+			if !strings.HasSuffix(line, "]") || strings.HasPrefix(line, "[[") {
+				return nil, &tomlError{i, "expect a table header like [diagnostics]"}
+			}
+			keys, err := splitTomlKey(line[1 : len(line)-1])
+			if err != nil {
+				return nil, &tomlError{i, err.Error()}
+			}
+			table, err := tomlTable(root, keys)
+			if err != nil {
+				return nil, &tomlError{i, err.Error()}
+			}
+			current = table
+			continue
+		}
+
+		rawKey, rawValue, found := strings.Cut(line, "=")
+		if !found {
+			return nil, &tomlError{i, "expect key = value"}
+		}
+		keys, err := splitTomlKey(rawKey)
+		if err != nil {
+			return nil, &tomlError{i, err.Error()}
+		}
+		value, rest, err := parseTomlValue(strings.TrimSpace(rawValue))
+		if err != nil {
+			return nil, &tomlError{i, err.Error()}
+		}
+		if strings.TrimSpace(rest) != "" {
+			return nil, &tomlError{i, fmt.Sprintf("unexpected %q after value", strings.TrimSpace(rest))}
+		}
+
+		table, err := tomlTable(current, keys[:len(keys)-1])
+		if err != nil {
+			return nil, &tomlError{i, err.Error()}
+		}
+		last := keys[len(keys)-1]
+		if _, exists := table[last]; exists {
+			return nil, &tomlError{i, fmt.Sprintf("duplicate key %s", last)}
+		}
+		table[last] = value
+	}
+
+	return root, nil
+}
+
+// Remove a trailing # comment that is not inside a string.
+func stripTomlComment(line string) string {
+	var quote rune
+	for i, c := range line {
+		switch {
+		case quote != 0 && c == quote:
+			quote = 0
+		case quote == 0 && (c == '"' || c == '\''):
+			quote = c
+		case quote == 0 && c == '#':
+			return line[:i]
+		}
+	}
+	return line
+}
+
+func splitTomlKey(raw string) ([]string, error) {
+	keys := []string{}
+	for _, part := range strings.Split(raw, ".") {
+		key := strings.Trim(strings.TrimSpace(part), `"'`)
+		if key == "" {
+			return nil, fmt.Errorf("empty key in %q", strings.TrimSpace(raw))
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+func tomlTable(root map[string]any, keys []string) (map[string]any, error) {
+	table := root
+	for _, key := range keys {
+		next, exists := table[key]
+		if !exists {
+			created := map[string]any{}
+			table[key] = created
+			table = created
+			continue
+		}
+		inner, ok := next.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("%s is already a value, not a table", key)
+		}
+		table = inner
+	}
+	return table, nil
+}
+
+// Parse one value off the front of text and return what is left.
+func parseTomlValue(text string) (any, string, error) {
+	if text == "" {
+		return nil, "", fmt.Errorf("missing value")
+	}
+
+	switch text[0] {
+	case '"':
+		end := 1
+		for end < len(text) && (text[end] != '"' || text[end-1] == '\\') {
+			end++
+		}
+		if end >= len(text) {
+			return nil, "", fmt.Errorf("unterminated string")
+		}
+		unquoted, err := strconv.Unquote(text[:end+1])
+		if err != nil {
+			return nil, "", fmt.Errorf("invalid string %s", text[:end+1])
+		}
+		return unquoted, text[end+1:], nil
+	case '\'':
+		end := strings.IndexRune(text[1:], '\'')
+		if end == -1 {
+			return nil, "", fmt.Errorf("unterminated string")
+		}
+		return text[1 : end+1], text[end+2:], nil
+	case '[':
+		items := []any{}
+		rest := strings.TrimSpace(text[1:])
+		for {
+			if strings.HasPrefix(rest, "]") {
+				return items, rest[1:], nil
+			}
+			item, after, err := parseTomlValue(rest)
+			if err != nil {
+				return nil, "", err
+			}
+			items = append(items, item)
+			rest = strings.TrimSpace(after)
+			if strings.HasPrefix(rest, ",") {
+				rest = strings.TrimSpace(rest[1:])
+			} else if !strings.HasPrefix(rest, "]") {
+				return nil, "", fmt.Errorf("expect , or ] in array")
+			}
+		}
+	}
+
+	end := strings.IndexAny(text, ",] \t")
+	if end == -1 {
+		end = len(text)
+	}
+	literal, rest := text[:end], text[end:]
+	switch literal {
+	case "true":
+		return true, rest, nil
+	case "false":
+		return false, rest, nil
+	}
+	cleaned := strings.ReplaceAll(literal, "_", "")
+	if integer, err := strconv.ParseInt(cleaned, 10, 64); err == nil {
+		return integer, rest, nil
+	}
+	if float, err := strconv.ParseFloat(cleaned, 64); err == nil {
+		return float, rest, nil
+	}
+	return nil, "", fmt.Errorf("invalid value %s", literal)
+}
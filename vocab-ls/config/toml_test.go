@@ -0,0 +1,57 @@
+package config
+
+import (
+	"testing"
+	test "vocab/vocab_testing"
+)
+
+func TestParseToml(t *testing.T) {
+	values, err := parseToml(`
+title = "vocab # not a comment" # a comment
+literal = 'C:\journal'
+count = 1_000
+ratio = -0.5
+enabled = false
+list = [ "vocab", 'md', ]
+dotted.key = 1
+
+[nested.table]
+inner = true
+`)
+
+	test.Expect(t, true, err == nil)
+	test.Expect(t, "vocab # not a comment", values["title"].(string))
+	test.Expect(t, `C:\journal`, values["literal"].(string))
+	test.Expect(t, int64(1000), values["count"].(int64))
+	test.Expect(t, -0.5, values["ratio"].(float64))
+	test.Expect(t, false, values["enabled"].(bool))
+	test.Expect(t, 2, len(values["list"].([]any)))
+	test.Expect(t, "md", values["list"].([]any)[1].(string))
+	test.Expect(t, int64(1), values["dotted"].(map[string]any)["key"].(int64))
+	test.Expect(t, true, values["nested"].(map[string]any)["table"].(map[string]any)["inner"].(bool))
+}
+
+func TestParseToml_Errors(t *testing.T) {
+	cases := []struct {
+		text string
+		line int
+	}{
+		{"a = ", 0},
+		{"a = 1\na = 2", 1},
+		{"\n[table", 1},
+		{"a = \"open", 0},
+		{"a = [1, 2", 0},
+		{"a = 1 2", 0},
+		{"a = 1\n[a]", 1},
+		{"a = yes", 0},
+	}
+
+	for _, c := range cases {
+		_, err := parseToml(c.text)
+		tomlErr, ok := err.(*tomlError)
+		if !ok {
+			t.Fatalf("Expected an error for %q, got %v", c.text, err)
+		}
+		test.Expect(t, c.line, tomlErr.line)
+	}
+}
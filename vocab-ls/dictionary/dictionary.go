@@ -0,0 +1,263 @@
+package dictionary
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// One headword of a dictionary and what it means.
+type Entry struct {
+	Word       string
+	Definition string
+}
+
+// The form words are looked up by, so that `Casa` finds `casa`.
+func Key(word string) string {
+	return strings.ToLower(strings.TrimSpace(word))
+}
+
+// Read every entry of the dictionary at path, by the format its extension tells:
+//
+//   - .tsv or .txt: one `word<TAB>definition` per line, `\n` in a definition starts a new line
+//   - .jsonl: one `{"word": ..., "definition": ...}` per line
+//   - .ifo: a StarDict dictionary, with its .idx and .dict (or .dict.dz) next to it
+//
+// Empty lines and lines starting with # are skipped in the line based formats.
+func Read(path string) ([]Entry, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".tsv", ".txt":
+		return readLines(path, parseTsvLine)
+	case ".jsonl":
+		return readLines(path, parseJsonLine)
+	case ".ifo":
+		return readStarDict(path)
+	}
+	return nil, fmt.Errorf("unknown dictionary format %q, expect .tsv, .txt, .jsonl or a StarDict .ifo", filepath.Ext(path))
+}
+
+// Files the dictionary at path is read from, whose changes call for a new index.
+func sourceFiles(path string) []string {
+	if !strings.EqualFold(filepath.Ext(path), ".ifo") {
+		return []string{path}
+	}
+	base := strings.TrimSuffix(path, filepath.Ext(path))
+	return []string{path, base + ".idx", base + ".idx.gz", base + ".dict", base + ".dict.dz"}
+}
+
+func readLines(path string, parse func(line string) (Entry, bool, error)) ([]Entry, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	entries := []Entry{}
+	scanner := bufio.NewScanner(file)
+	// definitions can be long
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSuffix(scanner.Text(), "\r")
+		if strings.TrimSpace(text) == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		entry, ok, err := parse(text)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, line, err)
+		}
+		if ok {
+			entries = append(entries, entry)
+		}
+	}
+	return entries, scanner.Err()
+}
+
+func parseTsvLine(line string) (Entry, bool, error) {
+	word, definition, found := strings.Cut(line, "\t")
+	if !found || strings.TrimSpace(word) == "" {
+		return Entry{}, false, nil
+	}
+	definition = strings.ReplaceAll(definition, `\n`, "\n")
+	return Entry{Word: strings.TrimSpace(word), Definition: strings.TrimSpace(definition)}, true, nil
+}
+
+func parseJsonLine(line string) (Entry, bool, error) {
+	var entry struct {
+		Word       string `json:"word"`
+		Definition string `json:"definition"`
+	}
+	if err := json.Unmarshal([]byte(line), &entry); err != nil {
+		return Entry{}, false, err
+	}
+	if strings.TrimSpace(entry.Word) == "" {
+		return Entry{}, false, nil
+	}
+	return Entry{Word: strings.TrimSpace(entry.Word), Definition: strings.TrimSpace(entry.Definition)}, true, nil
+}
+
+// https://github.com/huzheng001/stardict-3/blob/master/dict/doc/StarDictFileFormat
+func readStarDict(ifoPath string) ([]Entry, error) {
+	ifo, err := os.ReadFile(ifoPath)
+	if err != nil {
+		return nil, err
+	}
+	info := map[string]string{}
+	for line := range strings.Lines(string(ifo)) {
+		if key, value, found := strings.Cut(strings.TrimSpace(line), "="); found {
+			info[key] = value
+		}
+	}
+	offsetSize := 4
+	if info["idxoffsetbits"] == "64" {
+		offsetSize = 8
+	}
+	if version := info["version"]; version != "" && !strings.HasPrefix(version, "2.4") && !strings.HasPrefix(version, "3.0") {
+		return nil, fmt.Errorf("unsupported StarDict version %s", version)
+	}
+
+	base := strings.TrimSuffix(ifoPath, filepath.Ext(ifoPath))
+	idx, err := readMaybeGzipped(base+".idx", base+".idx.gz")
+	if err != nil {
+		return nil, err
+	}
+	dict, err := readMaybeGzipped(base+".dict", base+".dict.dz")
+	if err != nil {
+		return nil, err
+	}
+
+	entries := []Entry{}
+	for len(idx) > 0 {
+		end := bytes.IndexByte(idx, 0)
+		if end == -1 || len(idx) < end+1+offsetSize+4 {
+			return nil, fmt.Errorf("%s.idx: truncated entry", base)
+		}
+		word := string(idx[:end])
+		idx = idx[end+1:]
+		var offset uint64
+		if offsetSize == 8 {
+			offset = binary.BigEndian.Uint64(idx)
+		} else {
+			offset = uint64(binary.BigEndian.Uint32(idx))
+		}
+		size := uint64(binary.BigEndian.Uint32(idx[offsetSize:]))
+		idx = idx[offsetSize+4:]
+		if offset+size > uint64(len(dict)) {
+			return nil, fmt.Errorf("%s: %q points past the end of the .dict", base, word)
+		}
+
+		definition := starDictDefinition(dict[offset:offset+size], info["sametypesequence"])
+		entries = append(entries, Entry{Word: word, Definition: definition})
+	}
+	return entries, nil
+}
+
+// The first of paths that exists, decompressed if it ends in .gz or .dz.
+func readMaybeGzipped(paths ...string) ([]byte, error) {
+	for _, path := range paths {
+		file, err := os.Open(path)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+
+		if !strings.HasSuffix(path, ".gz") && !strings.HasSuffix(path, ".dz") {
+			return io.ReadAll(file)
+		}
+		// .dz is dictzip, gzip with an index of its chunks
+		reader, err := gzip.NewReader(file)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		return io.ReadAll(reader)
+	}
+	return nil, fmt.Errorf("none of %s exists", strings.Join(paths, ", "))
+}
+
+var markup = regexp.MustCompile(`<[^>]*>`)
+
+// The text fields of the data of one word. Each field has a type: lower case ones are text ended
+// by a NUL, upper case ones binary with their size in front. With sametypesequence, the types are
+// given once for every word and the last field runs to the end of the data.
+func starDictDefinition(data []byte, sameTypeSequence string) string {
+	fields := []string{}
+	add := func(kind byte, value []byte) {
+		text := string(value)
+		switch kind {
+		case 'm', 'l', 't', 'y':
+		case 'g', 'h', 'x', 'k', 'w':
+			text = html.UnescapeString(markup.ReplaceAllString(strings.ReplaceAll(text, "<br>", "\n"), ""))
+		default:
+			// sounds, pictures and the like
+			return
+		}
+		if text = strings.TrimSpace(text); text != "" {
+			fields = append(fields, text)
+		}
+	}
+	// one field of kind from the front of data, and what follows it
+	next := func(kind byte, last bool) ([]byte, []byte) {
+		if last {
+			return data, nil
+		}
+		if kind >= 'a' && kind <= 'z' {
+			end := bytes.IndexByte(data, 0)
+			if end == -1 {
+				return data, nil
+			}
+			return data[:end], data[end+1:]
+		}
+		if len(data) < 4 {
+			return nil, nil
+		}
+		size := min(int(binary.BigEndian.Uint32(data)), len(data)-4)
+		return data[4 : 4+size], data[4+size:]
+	}
+
+	if sameTypeSequence != "" {
+		for i := 0; i < len(sameTypeSequence) && data != nil; i++ {
+			var value []byte
+			value, data = next(sameTypeSequence[i], i == len(sameTypeSequence)-1)
+			add(sameTypeSequence[i], value)
+		}
+	} else {
+		for len(data) > 0 {
+			kind := data[0]
+			data = data[1:]
+			var value []byte
+			value, data = next(kind, false)
+			add(kind, value)
+		}
+	}
+	return strings.Join(fields, "\n")
+}
+
+// A short name for the dictionary at path, to tell its index apart from others.
+func stamp(path string) (string, error) {
+	parts := []string{path}
+	for _, file := range sourceFiles(path) {
+		info, err := os.Stat(file)
+		if os.IsNotExist(err) && file != path {
+			continue
+		}
+		if err != nil {
+			return "", err
+		}
+		parts = append(parts, strconv.FormatInt(info.Size(), 10), strconv.FormatInt(info.ModTime().UnixNano(), 10))
+	}
+	return strings.Join(parts, "\x00"), nil
+}
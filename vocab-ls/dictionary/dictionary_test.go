@@ -0,0 +1,206 @@
+package dictionary
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+	test "vocab/vocab_testing"
+)
+
+func write(t *testing.T, path string, content []byte) string {
+	if err := os.WriteFile(path, content, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestRead_Tsv(t *testing.T) {
+	path := write(t, filepath.Join(t.TempDir(), "it.tsv"), []byte("# header\ncasa\thouse\\nhome\n\ncane\tdog\r\n"))
+
+	entries, err := Read(path)
+
+	test.Expect(t, nil, err)
+	test.Expect(t, 2, len(entries))
+	test.Expect(t, "house\nhome", entries[0].Definition)
+	test.Expect(t, "dog", entries[1].Definition)
+}
+
+func TestRead_Jsonl(t *testing.T) {
+	path := write(t, filepath.Join(t.TempDir(), "de.jsonl"), []byte(`{"word": "unbestimmt", "definition": "undetermined"}`+"\n"))
+
+	entries, err := Read(path)
+
+	test.Expect(t, nil, err)
+	test.Expect(t, 1, len(entries))
+	test.Expect(t, "unbestimmt", entries[0].Word)
+	test.Expect(t, "undetermined", entries[0].Definition)
+}
+
+func TestRead_StarDict(t *testing.T) {
+	dir := t.TempDir()
+	var dict, idx bytes.Buffer
+	add := func(word string, data string) {
+		idx.WriteString(word + "\x00")
+		idx.Write(binary.BigEndian.AppendUint32(nil, uint32(dict.Len())))
+		idx.Write(binary.BigEndian.AppendUint32(nil, uint32(len(data))))
+		dict.WriteString(data)
+	}
+	add("cane", "dog")
+	add("casa", "<b>house</b><br>home")
+
+	write(t, filepath.Join(dir, "it.ifo"), []byte("StarDict's dict ifo file\nversion=2.4.2\nwordcount=2\nsametypesequence=h\n"))
+	write(t, filepath.Join(dir, "it.idx"), idx.Bytes())
+	var zipped bytes.Buffer
+	writer := gzip.NewWriter(&zipped)
+	writer.Write(dict.Bytes())
+	writer.Close()
+	write(t, filepath.Join(dir, "it.dict.dz"), zipped.Bytes())
+
+	entries, err := Read(filepath.Join(dir, "it.ifo"))
+
+	test.Expect(t, nil, err)
+	test.Expect(t, 2, len(entries))
+	test.Expect(t, "dog", entries[0].Definition)
+	test.Expect(t, "house\nhome", entries[1].Definition)
+}
+
+func TestStarDictDefinition_WithoutSameTypeSequence(t *testing.T) {
+	data := []byte("mhouse\x00W")
+	data = append(data, binary.BigEndian.AppendUint32(nil, 3)...)
+	data = append(data, "wav"...)
+	data = append(data, "tka:za\x00"...)
+
+	test.Expect(t, "house\nka:za", starDictDefinition(data, ""))
+}
+
+func TestIndex_ShouldLookUpAndComplete(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "it.idx")
+	long := strings.Repeat("a very long definition ", 100)
+	entries := []Entry{
+		{Word: "casa", Definition: "house"},
+		{Word: "Casa", Definition: "home"},
+		{Word: "cane", Definition: long},
+		{Word: "gatto", Definition: "cat"},
+		{Word: "casale", Definition: "farmhouse"},
+	}
+	test.Expect(t, nil, Build(path, entries, 1))
+
+	index, err := OpenIndex(path)
+	test.Expect(t, nil, err)
+	defer index.Close()
+	test.Expect(t, 4, index.Len())
+
+	entry, found, err := index.Lookup("CASA")
+	test.Expect(t, nil, err)
+	test.Expect(t, true, found)
+	test.Expect(t, "casa", entry.Word)
+	test.Expect(t, "house\n\nhome", entry.Definition)
+
+	entry, _, _ = index.Lookup("cane")
+	test.Expect(t, long, entry.Definition)
+
+	_, found, _ = index.Lookup("cas")
+	test.Expect(t, false, found)
+	_, found, _ = index.Lookup("zebra")
+	test.Expect(t, false, found)
+
+	completed, err := index.Complete("cas", 10)
+	test.Expect(t, nil, err)
+	test.Expect(t, 2, len(completed))
+	test.Expect(t, "casa", completed[0].Word)
+	test.Expect(t, "casale", completed[1].Word)
+}
+
+func TestLibrary_ShouldRebuildTheIndexOfAChangedDictionary(t *testing.T) {
+	dir := t.TempDir()
+	path := write(t, filepath.Join(dir, "it.tsv"), []byte("casa\thouse\n"))
+	library := NewLibrary(filepath.Join(dir, "cache"))
+	defer library.Close()
+
+	library.Configure(map[string]string{"it": path})
+	entry, found, err := library.Lookup("it", "casa")
+	test.Expect(t, nil, err)
+	test.Expect(t, true, found)
+	test.Expect(t, "house", entry.Definition)
+
+	_, found, _ = library.Lookup("de", "casa")
+	test.Expect(t, false, found)
+
+	write(t, path, []byte("casa\thome\n"))
+	// some file systems only keep seconds
+	later := time.Now().Add(time.Minute)
+	os.Chtimes(path, later, later)
+	library.Configure(map[string]string{"it": path})
+	entry, _, _ = library.Lookup("it", "casa")
+	test.Expect(t, "home", entry.Definition)
+}
+
+func TestLibrary_ShouldKeepIndexesOpenWhileTheyAreRead(t *testing.T) {
+	dir := t.TempDir()
+	path := write(t, filepath.Join(dir, "it.tsv"), []byte("casa\thouse\n"))
+	library := NewLibrary(filepath.Join(dir, "cache"))
+	library.Configure(map[string]string{"it": path})
+
+	configured := make(chan struct{})
+	err := library.use("it", func(index *Index) error {
+		// the dictionary goes away in the middle of a lookup
+		go func() {
+			library.Configure(nil)
+			close(configured)
+		}()
+		select {
+		case <-configured:
+			t.Error("closed an index still in use")
+		case <-time.After(50 * time.Millisecond):
+		}
+		_, found, err := index.Lookup("casa")
+		test.Expect(t, true, found)
+		return err
+	})
+	test.Expect(t, nil, err)
+
+	<-configured
+	_, found, err := library.Lookup("it", "casa")
+	test.Expect(t, nil, err)
+	test.Expect(t, false, found)
+}
+
+func BenchmarkIndex_Lookup(b *testing.B) {
+	entries := make([]Entry, 500_000)
+	for i := range entries {
+		entries[i] = Entry{Word: fmt.Sprintf("word%07d", i), Definition: "a definition"}
+	}
+	path := filepath.Join(b.TempDir(), "big.idx")
+	if err := Build(path, entries, 1); err != nil {
+		b.Fatal(err)
+	}
+	index, err := OpenIndex(path)
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer index.Close()
+
+	b.ResetTimer()
+	for i := range b.N {
+		if _, found, _ := index.Lookup(entries[i%len(entries)].Word); !found {
+			b.Fatal("not found")
+		}
+	}
+}
+
+func TestLemmas_ShouldOnlyTellInflectedForms(t *testing.T) {
+	path := write(t, filepath.Join(t.TempDir(), "it.txt"), []byte("entrambi\tentrambe\nentrambi\tentrambi\ncasa\tcase\ncaso\tcase\ncasa\tcasa\n"))
+
+	lemmas, err := ReadLemmas(path)
+
+	test.Expect(t, nil, err)
+	test.Expect(t, "entrambi", strings.Join(lemmas.Of("Entrambe"), ","))
+	test.Expect(t, "casa,caso", strings.Join(lemmas.Of("case"), ","))
+	test.Expect(t, 0, len(lemmas.Of("entrambi")), len(lemmas.Of("casa")), len(lemmas.Of("gatto")))
+}
@@ -0,0 +1,263 @@
+package dictionary
+
+import (
+	"bufio"
+	"cmp"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// An index is a dictionary sorted by key on disk, read with ReadAt rather than loaded, so that a
+// lookup costs a binary search of a few dozen small reads whatever the size of the dictionary.
+//
+// Layout, integers in little endian:
+//
+//	magic    [8]byte "VOCABDX1"
+//	stamp    uint64  hash of the files the index was built from
+//	count    uint64
+//	offsets  [count]uint64, of each record from the start of the records
+//	records  uvarint length and bytes of the key, the word and the definition
+type Index struct {
+	file    *os.File
+	stamp   uint64
+	count   int64
+	records int64
+}
+
+const (
+	indexMagic      = "VOCABDX1"
+	indexHeaderSize = 24
+)
+
+// A record is read in one go if it fits, most do.
+const recordReadSize = 256
+
+// Write entries as an index at path. Entries with the same key are merged into one, their
+// definitions one after the other.
+//
+// The index is written next to path first, then moved over it, so that a reader never sees half of
+// it.
+func Build(path string, entries []Entry, stamp uint64) error {
+	type record struct{ key, word, definition string }
+	records := make([]record, 0, len(entries))
+	for _, entry := range entries {
+		if key := Key(entry.Word); key != "" {
+			records = append(records, record{key, entry.Word, entry.Definition})
+		}
+	}
+	slices.SortStableFunc(records, func(a, b record) int { return cmp.Compare(a.key, b.key) })
+	merged := records[:0]
+	for _, r := range records {
+		if last := len(merged) - 1; last >= 0 && merged[last].key == r.key {
+			if r.definition != "" && merged[last].definition != r.definition {
+				merged[last].definition = strings.TrimSpace(merged[last].definition + "\n\n" + r.definition)
+			}
+			continue
+		}
+		merged = append(merged, r)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	temp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(temp.Name())
+
+	out := bufio.NewWriter(temp)
+	header := make([]byte, indexHeaderSize, indexHeaderSize+8*len(merged))
+	copy(header, indexMagic)
+	binary.LittleEndian.PutUint64(header[8:], stamp)
+	binary.LittleEndian.PutUint64(header[16:], uint64(len(merged)))
+	offset := uint64(0)
+	for _, r := range merged {
+		header = binary.LittleEndian.AppendUint64(header, offset)
+		offset += uint64(uvarintSize(len(r.key)) + len(r.key) + uvarintSize(len(r.word)) + len(r.word) + uvarintSize(len(r.definition)) + len(r.definition))
+	}
+	out.Write(header)
+	for _, r := range merged {
+		for _, field := range []string{r.key, r.word, r.definition} {
+			out.Write(binary.AppendUvarint(nil, uint64(len(field))))
+			out.WriteString(field)
+		}
+	}
+	if err := out.Flush(); err != nil {
+		temp.Close()
+		return err
+	}
+	if err := temp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(temp.Name(), path)
+}
+
+func uvarintSize(n int) int {
+	return len(binary.AppendUvarint(nil, uint64(n)))
+}
+
+var errNotAnIndex = errors.New("not a dictionary index")
+
+// Open the index at path. It stays open until Close.
+func OpenIndex(path string) (*Index, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	header := make([]byte, indexHeaderSize)
+	if _, err := file.ReadAt(header, 0); err != nil || string(header[:8]) != indexMagic {
+		file.Close()
+		return nil, fmt.Errorf("%s: %w", path, errNotAnIndex)
+	}
+	count := int64(binary.LittleEndian.Uint64(header[16:]))
+	return &Index{
+		file:    file,
+		stamp:   binary.LittleEndian.Uint64(header[8:]),
+		count:   count,
+		records: indexHeaderSize + 8*count,
+	}, nil
+}
+
+func (i *Index) Close() error {
+	return i.file.Close()
+}
+
+// Number of distinct keys.
+func (i *Index) Len() int {
+	return int(i.count)
+}
+
+// The entry of word, compared by Key.
+func (i *Index) Lookup(word string) (Entry, bool, error) {
+	key := Key(word)
+	at, err := i.search(key)
+	if err != nil || at == i.count {
+		return Entry{}, false, err
+	}
+	found, entry, err := i.record(at)
+	if err != nil || found != key {
+		return Entry{}, false, err
+	}
+	return entry, true, nil
+}
+
+// Up to limit entries whose key starts with the key of prefix, in order.
+func (i *Index) Complete(prefix string, limit int) ([]Entry, error) {
+	key := Key(prefix)
+	at, err := i.search(key)
+	if err != nil {
+		return nil, err
+	}
+	entries := []Entry{}
+	for ; at < i.count && len(entries) < limit; at++ {
+		found, entry, err := i.record(at)
+		if err != nil {
+			return nil, err
+		}
+		if !strings.HasPrefix(found, key) {
+			break
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// Position of the first record whose key is not less than key, count if there is none.
+func (i *Index) search(key string) (int64, error) {
+	low, high := int64(0), i.count
+	for low < high {
+		middle := low + (high-low)/2
+		found, err := i.key(middle)
+		if err != nil {
+			return 0, err
+		}
+		if found < key {
+			low = middle + 1
+		} else {
+			high = middle
+		}
+	}
+	return low, nil
+}
+
+// The key and entry of the record at position at.
+func (i *Index) record(at int64) (string, Entry, error) {
+	fields, err := i.fields(at, 3)
+	if err != nil {
+		return "", Entry{}, err
+	}
+	return fields[0], Entry{Word: fields[1], Definition: fields[2]}, nil
+}
+
+// The key alone of the record at position at, all a search needs.
+func (i *Index) key(at int64) (string, error) {
+	fields, err := i.fields(at, 1)
+	return fields[0], err
+}
+
+// The first count fields of the record at position at.
+func (i *Index) fields(at int64, count int) ([3]string, error) {
+	fields := [3]string{}
+	var offset [8]byte
+	if _, err := i.file.ReadAt(offset[:], indexHeaderSize+8*at); err != nil {
+		return fields, err
+	}
+	position := i.records + int64(binary.LittleEndian.Uint64(offset[:]))
+
+	buffer := make([]byte, recordReadSize)
+	read, err := i.file.ReadAt(buffer, position)
+	if err != nil && err != io.EOF {
+		return fields, err
+	}
+	buffer = buffer[:read]
+
+	for f := range count {
+		length, size := binary.Uvarint(buffer)
+		if size <= 0 {
+			// the length itself was cut off
+			if buffer, err = i.readMore(buffer, position, binary.MaxVarintLen64); err != nil {
+				return fields, err
+			}
+			if length, size = binary.Uvarint(buffer); size <= 0 {
+				return fields, errNotAnIndex
+			}
+		}
+		if need := size + int(length); need > len(buffer) {
+			if buffer, err = i.readMore(buffer, position, need); err != nil {
+				return fields, err
+			}
+			if need > len(buffer) {
+				return fields, errNotAnIndex
+			}
+		}
+		fields[f] = string(buffer[size : size+int(length)])
+		position += int64(size) + int64(length)
+		buffer = buffer[size+int(length):]
+	}
+	return fields, nil
+}
+
+// Read from position on again, need bytes of it or as many as there are.
+func (i *Index) readMore(buffer []byte, position int64, need int) ([]byte, error) {
+	more := make([]byte, max(need, 2*len(buffer)))
+	read, err := i.file.ReadAt(more, position)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	return more[:read], nil
+}
+
+// Hash of the stamp of a dictionary, as kept in the header of its index.
+func hashStamp(stamp string) uint64 {
+	hash := fnv.New64a()
+	hash.Write([]byte(stamp))
+	return hash.Sum64()
+}
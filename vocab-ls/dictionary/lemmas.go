@@ -0,0 +1,46 @@
+package dictionary
+
+import (
+	"slices"
+)
+
+// The citation forms of the inflected forms of one language: the infinitive of a verb, the
+// masculine singular of an adjective, the nominative singular of a noun.
+type Lemmas struct {
+	// Map of the key of a form and the citation forms it is an inflection of
+	forms map[string][]string
+	// Set of the keys of every citation form
+	citations map[string]struct{}
+}
+
+// Lemmas of entries whose words are citation forms and whose definitions are one of their forms.
+func NewLemmas(entries []Entry) *Lemmas {
+	lemmas := &Lemmas{forms: make(map[string][]string), citations: make(map[string]struct{})}
+	for _, entry := range entries {
+		lemmas.citations[Key(entry.Word)] = struct{}{}
+		form := Key(entry.Definition)
+		if form != "" && !slices.Contains(lemmas.forms[form], entry.Word) {
+			lemmas.forms[form] = append(lemmas.forms[form], entry.Word)
+		}
+	}
+	return lemmas
+}
+
+// Read the lemma list at path, one `lemma<TAB>form` per line like the lists of
+// https://github.com/michmech/lemmatization-lists.
+func ReadLemmas(path string) (*Lemmas, error) {
+	entries, err := readLines(path, parseTsvLine)
+	if err != nil {
+		return nil, err
+	}
+	return NewLemmas(entries), nil
+}
+
+// The citation forms form is an inflection of, nil if it is a citation form itself or unknown.
+func (l *Lemmas) Of(form string) []string {
+	key := Key(form)
+	if _, exists := l.citations[key]; exists {
+		return nil
+	}
+	return l.forms[key]
+}
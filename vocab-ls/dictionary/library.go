@@ -0,0 +1,167 @@
+package dictionary
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// The dictionaries of every language, each indexed once into cacheDir and opened on first use.
+//
+// Safe to use from any goroutine.
+type Library struct {
+	cacheDir string
+	mutex    sync.Mutex
+	// Map of language code and its dictionary
+	dictionaries map[string]*dictionary
+}
+
+type dictionary struct {
+	source   string
+	cacheDir string
+	// Sizes and times of the files of source when it was configured
+	stamp string
+	once  sync.Once
+	index *Index
+	err   error
+	// Lookups and completions using the index, which is only closed once they are done
+	users sync.WaitGroup
+}
+
+func NewLibrary(cacheDir string) *Library {
+	return &Library{cacheDir: cacheDir, dictionaries: make(map[string]*dictionary)}
+}
+
+// Where indexes are kept unless told otherwise.
+func DefaultCacheDir() string {
+	cache, err := os.UserCacheDir()
+	if err != nil {
+		cache = os.TempDir()
+	}
+	return filepath.Join(cache, "vocab", "dictionaries")
+}
+
+// Use the dictionaries of paths, a map of language code and the path of its dictionary.
+//
+// Dictionaries whose files did not change are kept open. Others are indexed in the background, so
+// that the first lookup does not have to wait for it.
+func (l *Library) Configure(paths map[string]string) {
+	l.mutex.Lock()
+	removed := []*dictionary{}
+	for lang, existing := range l.dictionaries {
+		if paths[lang] != existing.source || existing.changed() {
+			removed = append(removed, existing)
+			delete(l.dictionaries, lang)
+		}
+	}
+	for lang, source := range paths {
+		if _, exists := l.dictionaries[lang]; exists {
+			continue
+		}
+		d := &dictionary{source: source, cacheDir: l.cacheDir}
+		d.stamp, _ = stamp(source)
+		l.dictionaries[lang] = d
+		go d.open()
+	}
+	l.mutex.Unlock()
+
+	for _, d := range removed {
+		d.close()
+	}
+}
+
+// The entry of word in the dictionary of lang, if there is one.
+func (l *Library) Lookup(lang string, word string) (entry Entry, found bool, err error) {
+	err = l.use(lang, func(index *Index) error {
+		entry, found, err = index.Lookup(word)
+		return err
+	})
+	return entry, found, err
+}
+
+// Up to limit entries of the dictionary of lang starting with prefix.
+func (l *Library) Complete(lang string, prefix string, limit int) (entries []Entry, err error) {
+	err = l.use(lang, func(index *Index) error {
+		entries, err = index.Complete(prefix, limit)
+		return err
+	})
+	return entries, err
+}
+
+func (l *Library) Close() {
+	l.Configure(nil)
+}
+
+// Run use with the index of the dictionary of lang, if there is one. The index stays open until
+// use returns, even if the dictionary is configured away meanwhile.
+func (l *Library) use(lang string, use func(index *Index) error) error {
+	l.mutex.Lock()
+	d := l.dictionaries[lang]
+	if d != nil {
+		// counted before the dictionary can be removed, and so before close waits for it
+		d.users.Add(1)
+	}
+	l.mutex.Unlock()
+	if d == nil {
+		return nil
+	}
+	defer d.users.Done()
+
+	d.open()
+	if d.index == nil || d.err != nil {
+		return d.err
+	}
+	return use(d.index)
+}
+
+// Open the index of the dictionary, building it first if the dictionary changed since it was
+// built.
+func (d *dictionary) open() {
+	d.once.Do(func() {
+		d.index, d.err = openOrBuild(d.source, d.cacheDir)
+	})
+}
+
+func (d *dictionary) changed() bool {
+	current, _ := stamp(d.source)
+	return current != d.stamp
+}
+
+func (d *dictionary) close() {
+	// waits for an index still being built, then for whoever is still reading it
+	d.open()
+	d.users.Wait()
+	if d.index != nil {
+		d.index.Close()
+	}
+}
+
+func openOrBuild(source string, cacheDir string) (*Index, error) {
+	absolute, err := filepath.Abs(source)
+	if err != nil {
+		return nil, err
+	}
+	stamped, err := stamp(absolute)
+	if err != nil {
+		return nil, fmt.Errorf("can't read dictionary: %w", err)
+	}
+	hash := hashStamp(stamped)
+	path := filepath.Join(cacheDir, fmt.Sprintf("%016x.idx", hashStamp(absolute)))
+
+	if index, err := OpenIndex(path); err == nil {
+		if index.stamp == hash {
+			return index, nil
+		}
+		index.Close()
+	}
+
+	entries, err := Read(absolute)
+	if err != nil {
+		return nil, fmt.Errorf("can't read dictionary: %w", err)
+	}
+	if err := Build(path, entries, hash); err != nil {
+		return nil, fmt.Errorf("can't index dictionary %s: %w", absolute, err)
+	}
+	return OpenIndex(path)
+}
@@ -0,0 +1,194 @@
+package export
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"vocab/vocabulary/forest"
+	"vocab/vocabulary/parser"
+)
+
+// The shape history is written in.
+type Format string
+
+const (
+	// An array of rows, for notebooks and scripts
+	FormatJson Format = "json"
+	// A header and one line per row, for spreadsheets
+	FormatCsv Format = "csv"
+	// Tab separated notes with front, back and tags, for Anki's File > Import
+	FormatAnki Format = "anki"
+)
+
+var Formats = []Format{FormatJson, FormatCsv, FormatAnki}
+
+func ParseFormat(name string) (Format, error) {
+	format := Format(strings.ToLower(name))
+	if !slices.Contains(Formats, format) {
+		return "", fmt.Errorf("unknown export format %q, expect json, csv or anki", name)
+	}
+	return format, nil
+}
+
+// One appearance of a word, as exported.
+type Row struct {
+	Word  string `json:"word"`
+	Text  string `json:"text"`
+	Gloss string `json:"gloss"`
+	Lang  string `json:"lang"`
+	Date  string `json:"date"`
+	// Absent if only the production grade was written, as in word(/3)
+	Grade *int `json:"grade,omitempty"`
+	// Graded in both directions, as in word(5/3)
+	TwoWay bool `json:"twoWay"`
+	// Absent unless TwoWay and written
+	ProductionGrade *int   `json:"productionGrade,omitempty"`
+	Reviewed        bool   `json:"reviewed"`
+	File            string `json:"file"`
+	// Starts at 1, like in an editor
+	Line       int      `json:"line"`
+	Utterances []string `json:"utterances"`
+}
+
+// Turn reviews into rows, with file names made by path, or left as uris if path is nil.
+func NewRows(reviews []forest.Review, path func(uri string) string) []Row {
+	rows := make([]Row, 0, len(reviews))
+	for _, review := range reviews {
+		file := review.Uri
+		if path != nil {
+			file = path(review.Uri)
+		}
+		row := Row{
+			Word:       review.Word,
+			Text:       review.Text,
+			Gloss:      review.Gloss,
+			Lang:       review.Lang,
+			Date:       review.Date.Format(time.DateOnly),
+			Grade:      grade(review.Grade),
+			TwoWay:     review.TwoWay,
+			Reviewed:   review.Reviewed,
+			File:       file,
+			Line:       review.Line + 1,
+			Utterances: review.Utterances,
+		}
+		if review.TwoWay {
+			row.ProductionGrade = grade(review.ProductionGrade)
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+// nil for a grade left out.
+func grade(g int) *int {
+	if g == parser.Ungraded {
+		return nil
+	}
+	return &g
+}
+
+// The grade as a cell, empty if left out.
+func gradeCell(g *int) string {
+	if g == nil {
+		return ""
+	}
+	return strconv.Itoa(*g)
+}
+
+// Write rows to out in format.
+func Write(out io.Writer, format Format, rows []Row) error {
+	switch format {
+	case FormatJson:
+		return writeJson(out, rows)
+	case FormatCsv:
+		return writeCsv(out, rows)
+	case FormatAnki:
+		return writeAnki(out, rows)
+	}
+	return fmt.Errorf("unknown export format %q", format)
+}
+
+func writeJson(out io.Writer, rows []Row) error {
+	encoder := json.NewEncoder(out)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(rows)
+}
+
+func writeCsv(out io.Writer, rows []Row) error {
+	writer := csv.NewWriter(out)
+	writer.Write([]string{"word", "text", "lang", "date", "grade", "reviewed", "file", "line", "utterances", "gloss", "twoWay", "productionGrade"})
+	for _, row := range rows {
+		writer.Write([]string{
+			row.Word,
+			row.Text,
+			row.Lang,
+			row.Date,
+			gradeCell(row.Grade),
+			strconv.FormatBool(row.Reviewed),
+			row.File,
+			strconv.Itoa(row.Line),
+			strings.Join(row.Utterances, "\n"),
+			row.Gloss,
+			strconv.FormatBool(row.TwoWay),
+			gradeCell(row.ProductionGrade),
+		})
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// One note per word rather than per row, or Anki would ask for the same word once for every time
+// it was written down. The back is the latest gloss of the word, then the utterances of the latest
+// section the word appears in.
+func writeAnki(out io.Writer, rows []Row) error {
+	type note struct{ front, gloss, back, tags string }
+	notes := []*note{}
+	byWord := map[string]*note{}
+	// rows come ordered by date, later ones win
+	for _, row := range rows {
+		key := row.Lang + "\x00" + row.Text
+		n, exists := byWord[key]
+		if !exists {
+			n = &note{}
+			byWord[key] = n
+			notes = append(notes, n)
+		}
+		back := make([]string, 0, len(row.Utterances))
+		for _, utterance := range row.Utterances {
+			back = append(back, html.EscapeString(utterance))
+		}
+		n.front = html.EscapeString(row.Word)
+		if row.Gloss != "" {
+			n.gloss = html.EscapeString(row.Gloss)
+		}
+		n.back = strings.Join(back, "<br>")
+		n.tags = "vocab " + row.Lang
+	}
+
+	// https://docs.ankiweb.net/importing/text-files.html#file-headers
+	if _, err := io.WriteString(out, "#separator:tab\n#html:true\n#tags column:3\n"); err != nil {
+		return err
+	}
+	for _, n := range notes {
+		if n.gloss != "" && n.back != "" {
+			n.back = "<b>" + n.gloss + "</b><br>" + n.back
+		} else if n.gloss != "" {
+			n.back = "<b>" + n.gloss + "</b>"
+		}
+		if _, err := fmt.Fprintf(out, "%s\t%s\t%s\n", ankiField(n.front), ankiField(n.back), n.tags); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Tabs and new lines would start another field or note.
+func ankiField(text string) string {
+	return strings.NewReplacer("\t", " ", "\r", "", "\n", "<br>").Replace(text)
+}
@@ -0,0 +1,105 @@
+package export
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+	"vocab/lib"
+	test "vocab/vocab_testing"
+	"vocab/vocabulary/forest"
+)
+
+func history(t *testing.T) []forest.Review {
+	f := forest.NewForest(t.Context(), func(any) {}).WithClock(lib.FixedClock{Time: time.Date(2025, time.June, 9, 0, 0, 0, 0, time.UTC)})
+	f.Plant("file:///journal/it.vocab", test.TrimLines(`
+		01/06/2025
+		> (it) la casa(3)
+		La casa è "grande".
+		08/06/2025
+		>> (it) casa(5) = home
+		Torno a	casa.
+	`), nil)
+	return f.History()
+}
+
+func TestWrite_Json_ShouldHaveOneRowPerTwig(t *testing.T) {
+	var out bytes.Buffer
+	test.Expect(t, nil, Write(&out, FormatJson, NewRows(history(t), nil)))
+
+	rows := []Row{}
+	if err := json.Unmarshal(out.Bytes(), &rows); err != nil {
+		t.Fatal(err)
+	}
+	test.Expect(t, 2, len(rows))
+	test.Expect(t, "la casa", rows[0].Word)
+	test.Expect(t, "casa", rows[0].Text)
+	test.Expect(t, "it", rows[0].Lang)
+	test.Expect(t, "2025-06-01", rows[0].Date)
+	test.Expect(t, 3, *rows[0].Grade)
+	test.Expect(t, false, rows[0].TwoWay)
+	test.Expect(t, nil, rows[0].ProductionGrade)
+	test.Expect(t, false, rows[0].Reviewed)
+	test.Expect(t, 2, rows[0].Line)
+	test.Expect(t, "file:///journal/it.vocab", rows[0].File)
+	test.Expect(t, `La casa è "grande".`, rows[0].Utterances[0])
+	test.Expect(t, "", rows[0].Gloss)
+	test.Expect(t, true, rows[1].Reviewed)
+	test.Expect(t, "home", rows[1].Gloss)
+}
+
+func TestWrite_Json_ShouldLeaveGradesThatAreNotWrittenOut(t *testing.T) {
+	f := forest.NewForest(t.Context(), func(any) {})
+	f.Plant("file:///de.vocab", "01/06/2025\n> (de) Haus(5/3), Hund(/3)", nil)
+	var out bytes.Buffer
+	test.Expect(t, nil, Write(&out, FormatJson, NewRows(f.History(), nil)))
+
+	test.Expect(t, false, strings.Contains(out.String(), "-1"))
+	rows := []Row{}
+	if err := json.Unmarshal(out.Bytes(), &rows); err != nil {
+		t.Fatal(err)
+	}
+	test.Expect(t, 5, *rows[0].Grade)
+	test.Expect(t, true, rows[0].TwoWay)
+	test.Expect(t, 3, *rows[0].ProductionGrade)
+	test.Expect(t, nil, rows[1].Grade)
+	test.Expect(t, 3, *rows[1].ProductionGrade)
+}
+
+func TestWrite_Csv_ShouldQuoteWhatNeedsQuoting(t *testing.T) {
+	var out bytes.Buffer
+	rows := NewRows(history(t), func(uri string) string { return strings.TrimPrefix(uri, "file://") })
+	test.Expect(t, nil, Write(&out, FormatCsv, rows))
+
+	records, err := csv.NewReader(&out).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	test.Expect(t, 3, len(records))
+	test.Expect(t, "word", records[0][0])
+	test.Expect(t, "/journal/it.vocab", records[1][6])
+	test.Expect(t, `La casa è "grande".`, records[1][8])
+	test.Expect(t, "home", records[2][9])
+}
+
+func TestWrite_Anki_ShouldHaveOneNotePerWord(t *testing.T) {
+	var out bytes.Buffer
+	test.Expect(t, nil, Write(&out, FormatAnki, NewRows(history(t), nil)))
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	test.Expect(t, 4, len(lines))
+	test.Expect(t, "#separator:tab", lines[0])
+	// the latest section wins, and the tab of the utterance can't split the note
+	test.Expect(t, "casa\t<b>home</b><br>Torno a casa.\tvocab it", lines[3])
+}
+
+func TestParseFormat(t *testing.T) {
+	format, err := ParseFormat("CSV")
+	test.Expect(t, nil, err)
+	test.Expect(t, FormatCsv, format)
+
+	_, err = ParseFormat("xlsx")
+	test.Expect(t, true, err != nil)
+}
@@ -0,0 +1,55 @@
+package harvester
+
+import (
+	"context"
+	"time"
+)
+
+// Call onChange whenever the local calendar day changes, or the wall clock jumps, which is what
+// waking up from sleep looks like: the monotonic clock stands still while the machine sleeps.
+// Every other tick of interval calls onTick.
+//
+// Blocks until ctx is done.
+func watchDays(ctx context.Context, interval time.Duration, onChange func(), onTick func()) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	last := time.Now()
+	day := last.Format(time.DateOnly)
+	midnight := time.NewTimer(untilMidnight(last))
+	defer midnight.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-midnight.C:
+		case <-ticker.C:
+		}
+
+		now := time.Now()
+		jumped := clockJumped(now.Sub(last), now.Round(0).Sub(last.Round(0)), interval)
+		last = now
+		midnight.Reset(untilMidnight(now))
+
+		if today := now.Format(time.DateOnly); today != day || jumped {
+			day = today
+			onChange()
+		} else {
+			onTick()
+		}
+	}
+}
+
+// Time left until the next local midnight.
+func untilMidnight(now time.Time) time.Duration {
+	year, month, day := now.Date()
+	return time.Date(year, month, day+1, 0, 0, 0, 0, now.Location()).Sub(now)
+}
+
+// Whether the wall clock moved away from the monotonic clock by more than tolerance over the
+// same period.
+func clockJumped(monotonic time.Duration, wall time.Duration, tolerance time.Duration) bool {
+	drift := wall - monotonic
+	return drift > tolerance || drift < -tolerance
+}
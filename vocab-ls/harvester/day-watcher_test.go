@@ -0,0 +1,76 @@
+package harvester
+
+import (
+	"testing"
+	"time"
+	"vocab/lib"
+	test "vocab/vocab_testing"
+)
+
+func TestUntilMidnight(t *testing.T) {
+	now := time.Date(2025, 12, 31, 23, 30, 0, 0, time.Local)
+	test.Expect(t, 30*time.Minute, untilMidnight(now))
+}
+
+func TestClockJumped(t *testing.T) {
+	test.Expect(t, false, clockJumped(time.Minute, time.Minute+time.Second, time.Minute))
+	// slept for 8 hours
+	test.Expect(t, true, clockJumped(time.Minute, 8*time.Hour, time.Minute))
+	// clock set back
+	test.Expect(t, true, clockJumped(time.Minute, -time.Hour, time.Minute))
+}
+
+func TestDayChanged_ShouldRefreshOnceInitialized(t *testing.T) {
+	h, sent := newTestHarvester(t)
+	h.request(t, "initialize", map[string]any{
+		"capabilities": map[string]any{
+			"workspace":    map[string]any{"diagnostics": map[string]any{"refreshSupport": true}},
+			"textDocument": map[string]any{"diagnostic": map[string]any{}},
+		},
+	})
+
+	h.workspace.DayChanged()
+	test.Expect(t, 0, len(sent.methods()))
+
+	h.notify(t, "initialized", map[string]any{})
+	h.workspace.DayChanged()
+	methods := sent.methods()
+	test.Expect(t, "workspace/diagnostic/refresh", methods[len(methods)-1])
+}
+
+func TestMinutePassed_ShouldRefreshWhileWordsAreLearning(t *testing.T) {
+	h, sent := newTestHarvester(t)
+	h.workspace.forest.WithClock(lib.FixedClock{Time: time.Date(2025, time.May, 20, 18, 35, 0, 0, time.Local)})
+	h.request(t, "initialize", map[string]any{
+		"capabilities": map[string]any{
+			"workspace":    map[string]any{"diagnostics": map[string]any{"refreshSupport": true}},
+			"textDocument": map[string]any{"diagnostic": map[string]any{}},
+		},
+	})
+	h.notify(t, "initialized", map[string]any{})
+	h.notify(t, "textDocument/didOpen", map[string]any{
+		"textDocument": map[string]any{"uri": "file:///a.vocab", "text": "20/05/2025 18:30\n> (it) casa(1)"},
+	})
+	refreshes := func() int {
+		count := 0
+		for _, method := range sent.methods() {
+			if method == "workspace/diagnostic/refresh" {
+				count++
+			}
+		}
+		return count
+	}
+
+	// due tomorrow without learning steps, nothing counts down
+	h.workspace.forest.Settled()
+	before := refreshes()
+	h.workspace.MinutePassed()
+	test.Expect(t, before, refreshes())
+
+	h.notify(t, "workspace/didChangeConfiguration", map[string]any{
+		"settings": map[string]any{"vocab": map[string]any{"scheduler": map[string]any{"learningSteps": []any{"10m"}}}},
+	})
+	before = refreshes()
+	h.workspace.MinutePassed()
+	test.Expect(t, before+1, refreshes())
+}
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"sync"
 	"vocab/lib"
 	lsproto "vocab/lsp"
 )
@@ -12,6 +13,15 @@ import (
 type ReadCallback = func() ([]byte, error)
 type WriteCallback = func(any)
 
+// Client sends messages to the language client outside of the usual request-response cycle.
+type Client interface {
+	// Send a request to the client. onResponse, if any, is called from the main loop with the answer.
+	Request(method string, params any, onResponse func(lsproto.ResponseMessage))
+	Notify(method string, params any)
+}
+
+var _ Client = (*Engine)(nil)
+
 type Engine struct {
 	ctx                  context.Context
 	read                 ReadCallback
@@ -19,6 +29,12 @@ type Engine struct {
 	logger               lib.Logger
 	notificationHandlers map[string]func(lsproto.Notification) (any, error)
 	requestHandlers      map[string]func(lsproto.RequestMessage) (any, error)
+
+	// Messages can be sent from outside of the main loop
+	writeMutex sync.Mutex
+	// Map of request id sent to the client and what to do with its response
+	pendingRequests map[int]func(lsproto.ResponseMessage)
+	nextRequestId   int
 }
 
 func NewEngine(
@@ -34,6 +50,9 @@ func NewEngine(
 		logger,
 		make(map[string]func(lsproto.Notification) (any, error)),
 		make(map[string]func(lsproto.RequestMessage) (any, error)),
+		sync.Mutex{},
+		make(map[int]func(lsproto.ResponseMessage)),
+		0,
 	}
 	return engine
 }
@@ -71,7 +90,7 @@ func (engine *Engine) Start() *Engine {
 				if response == nil {
 					continue
 				}
-				engine.write(response)
+				engine.send(response)
 			}
 		case lsproto.MessageKindRequest:
 			if r, ok := data.Msg.(lsproto.RequestMessage); ok {
@@ -84,11 +103,12 @@ func (engine *Engine) Start() *Engine {
 				if response == nil {
 					continue
 				}
-				engine.write(response)
+				engine.send(response)
 			}
 		case lsproto.MessageKindResponse:
 			if r, ok := data.Msg.(lsproto.ResponseMessage); ok {
-				engine.logger.Log(r.ID)
+				engine.logger.Log("Received response ", r.ID)
+				engine.onResponse(r)
 			}
 		default:
 			engine.logger.Log("No default message handler found.")
@@ -96,6 +116,52 @@ func (engine *Engine) Start() *Engine {
 	}
 }
 
+func (engine *Engine) Request(method string, params any, onResponse func(lsproto.ResponseMessage)) {
+	engine.writeMutex.Lock()
+	id := engine.nextRequestId
+	engine.nextRequestId++
+	if onResponse != nil {
+		engine.pendingRequests[id] = onResponse
+	}
+	engine.writeMutex.Unlock()
+
+	engine.send(map[string]any{
+		"jsonrpc": "2.0",
+		"id":      id,
+		"method":  method,
+		"params":  params,
+	})
+}
+
+func (engine *Engine) Notify(method string, params any) {
+	engine.send(map[string]any{
+		"jsonrpc": "2.0",
+		"method":  method,
+		"params":  params,
+	})
+}
+
+func (engine *Engine) send(message any) {
+	engine.writeMutex.Lock()
+	defer engine.writeMutex.Unlock()
+	engine.write(message)
+}
+
+func (engine *Engine) onResponse(message lsproto.ResponseMessage) {
+	engine.writeMutex.Lock()
+	handler := engine.pendingRequests[message.ID]
+	delete(engine.pendingRequests, message.ID)
+	engine.writeMutex.Unlock()
+
+	if handler == nil {
+		return
+	}
+	if message.Error != nil {
+		engine.logger.Logf("Client answered request %d with error %+v", message.ID, message.Error)
+	}
+	handler(message)
+}
+
 func (engine *Engine) onRequest(message lsproto.RequestMessage) (any, error) {
 	handler := engine.requestHandlers[message.Method]
 	if handler == nil {
@@ -2,13 +2,16 @@ package harvester
 
 import (
 	"context"
+	"time"
 	"vocab/lib"
 	lsproto "vocab/lsp"
 	"vocab/vocabulary/forest"
 )
 
 type Harvester struct {
+	ctx                context.Context
 	engine             *Engine
+	workspace          *Workspace
 	notificationWorker *NotificationWorker
 	requestWorker      *RequestWorker
 }
@@ -20,26 +23,50 @@ func NewHarvester(
 	writeCallback func(msg any),
 	logger lib.Logger,
 ) *Harvester {
+	engine := NewEngine(ctx, readCallback, writeCallback, logger)
+	workspace := NewWorkspace(forest, engine, logger)
 	h := &Harvester{
-		engine:             NewEngine(ctx, readCallback, writeCallback, logger),
-		notificationWorker: NewNotificationWorker(forest),
-		requestWorker:      NewRequestWorker(forest, logger),
+		ctx:                ctx,
+		engine:             engine,
+		workspace:          workspace,
+		notificationWorker: NewNotificationWorker(forest, workspace),
+		requestWorker:      NewRequestWorker(forest, workspace, logger),
 	}
 
 	h.engine.SetNotificationHandlers(map[string]func(lsproto.Notification) (any, error){
-		"workspace/didDeleteFiles": h.notificationWorker.DeleteFileWorker,
-		"textDocument/didOpen":     h.notificationWorker.DidOpenWorker,
-		"textDocument/didChange":   h.notificationWorker.DidChangeWorker,
+		"workspace/didDeleteFiles":            h.notificationWorker.DeleteFileWorker,
+		"workspace/didChangeWorkspaceFolders": h.notificationWorker.DidChangeWorkspaceFoldersWorker,
+		"textDocument/didOpen":                h.notificationWorker.DidOpenWorker,
+		"textDocument/didChange":              h.notificationWorker.DidChangeWorker,
+		"initialized":                         h.notificationWorker.InitializedWorker,
+		"workspace/didChangeConfiguration":    h.notificationWorker.DidChangeConfigurationWorker,
+		"workspace/didChangeWatchedFiles":     h.notificationWorker.DidChangeWatchedFilesWorker,
 	}).SetRequestHandlers(map[string]func(lsproto.RequestMessage) (any, error){
-		"vocab/collectFromThisFile": h.requestWorker.CollectFromThisFileWorker,
-		"vocab/collectAll":          h.requestWorker.CollectFromAllFilesWorker,
-		"textDocument/diagnostic":   h.requestWorker.TextDocumentDiagnosticsWorker,
-		"initialize":                h.requestWorker.InitializeWorker,
+		"vocab/collectFromThisFile":        h.requestWorker.CollectFromThisFileWorker,
+		"vocab/collectAll":                 h.requestWorker.CollectFromAllFilesWorker,
+		"vocab/forecast":                   h.requestWorker.ForecastWorker,
+		"vocab/stats":                      h.requestWorker.StatsWorker,
+		"vocab/leeches":                    h.requestWorker.LeechesWorker,
+		"vocab/cloze":                      h.requestWorker.ClozeWorker,
+		"vocab/quiz/start":                 h.requestWorker.QuizStartWorker,
+		"vocab/quiz/next":                  h.requestWorker.QuizNextWorker,
+		"vocab/quiz/answer":                h.requestWorker.QuizAnswerWorker,
+		"vocab/export":                     h.requestWorker.ExportWorker,
+		"vocab/import":                     h.requestWorker.ImportWorker,
+		"textDocument/hover":               h.requestWorker.HoverWorker,
+		"textDocument/completion":          h.requestWorker.CompletionWorker,
+		"textDocument/semanticTokens/full": h.requestWorker.SemanticTokensWorker,
+		"textDocument/codeAction":          h.requestWorker.CodeActionWorker,
+		"textDocument/diagnostic":          h.requestWorker.TextDocumentDiagnosticsWorker,
+		"workspace/diagnostic":             h.requestWorker.WorkspaceDiagnosticsWorker,
+		"initialize":                       h.requestWorker.InitializeWorker,
 	})
 
 	return h
 }
 
 func (h *Harvester) Start() {
+	// due words change with the day, or the minute while learning, even if nothing is edited
+	go watchDays(h.ctx, time.Minute, h.workspace.DayChanged, h.workspace.MinutePassed)
 	h.engine.Start()
 }
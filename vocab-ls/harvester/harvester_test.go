@@ -0,0 +1,257 @@
+package harvester
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+	"vocab/config"
+	"vocab/lib"
+	lsproto "vocab/lsp"
+	test "vocab/vocab_testing"
+	"vocab/vocabulary/forest"
+	"vocab/vocabulary/parser"
+)
+
+// Every message a harvester wrote to its client, decoded.
+type sentMessages struct {
+	mutex    sync.Mutex
+	messages []map[string]any
+}
+
+func (s *sentMessages) write(message any) {
+	encoded, _ := json.Marshal(message)
+	decoded := map[string]any{}
+	json.Unmarshal(encoded, &decoded)
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.messages = append(s.messages, decoded)
+}
+
+// Methods of the requests and notifications sent so far, in order.
+func (s *sentMessages) methods() []string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	methods := []string{}
+	for _, message := range s.messages {
+		if method, ok := message["method"].(string); ok {
+			methods = append(methods, method)
+		}
+	}
+	return methods
+}
+
+// A harvester that is sent messages through the handlers of its engine, as the main loop would.
+func newTestHarvester(t *testing.T) (*Harvester, *sentMessages) {
+	sent := &sentMessages{}
+	f := forest.NewForest(t.Context(), func(any) {})
+	h := NewHarvester(t.Context(), f, func() ([]byte, error) { return nil, io.EOF }, sent.write, lib.NewLogger(io.Discard))
+	t.Cleanup(func() { h.workspace.Dictionaries().Close() })
+	return h, sent
+}
+
+func (h *Harvester) request(t *testing.T, method string, params map[string]any) any {
+	response, err := h.engine.onRequest(lsproto.RequestMessage{ID: 1, Method: method, Params: params})
+	test.Expect(t, nil, err)
+	return response
+}
+
+func (h *Harvester) notify(t *testing.T, method string, params any) {
+	_, err := h.engine.onNotification(lsproto.Notification{Method: method, Params: params})
+	test.Expect(t, nil, err)
+}
+
+func TestHarvester_ShouldFollowClientSettingsAndVocabrc(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		return
+	}
+
+	root := t.TempDir()
+	writeVocabFile(t, filepath.Join(root, "it.vocab"))
+	h, sent := newTestHarvester(t)
+	h.request(t, "initialize", map[string]any{
+		"capabilities": map[string]any{
+			"workspace": map[string]any{
+				"configuration":         true,
+				"didChangeWatchedFiles": map[string]any{"dynamicRegistration": true},
+			},
+		},
+		"workspaceFolders": []any{map[string]any{"uri": "file://" + root, "name": "root"}},
+	})
+
+	h.notify(t, "initialized", map[string]any{})
+	test.Expect(t, true, slices.Contains(sent.methods(), "client/registerCapability"))
+	test.Expect(t, true, slices.Contains(sent.methods(), "workspace/configuration"))
+
+	h.notify(t, "workspace/didChangeConfiguration", map[string]any{
+		"settings": map[string]any{"vocab": map[string]any{"diagnostics": map[string]any{"errorWithinDays": 2}}},
+	})
+	test.Expect(t, 2.0, h.notificationWorker.workspace.Config().Diagnostics.ErrorWithinDays)
+
+	os.WriteFile(filepath.Join(root, ".vocabrc"), []byte("[diagnostics]\nhintWithinDays = 9\n"), 0o644)
+	h.notify(t, "workspace/didChangeWatchedFiles", map[string]any{
+		"changes": []any{map[string]any{"uri": "file://" + root + "/.vocabrc", "type": 2}},
+	})
+	test.Expect(t, 9.0, h.notificationWorker.workspace.Config().Diagnostics.HintWithinDays)
+	// the client settings still win over the file
+	test.Expect(t, 2.0, h.notificationWorker.workspace.Config().Diagnostics.ErrorWithinDays)
+}
+
+func TestHarvester_ShouldPushDiagnosticsOnceInitialized(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		return
+	}
+
+	root := t.TempDir()
+	writeVocabFile(t, filepath.Join(root, "it.vocab"))
+	h, sent := newTestHarvester(t)
+	// no textDocument.diagnostic, the client can't pull
+	h.request(t, "initialize", map[string]any{
+		"capabilities":     map[string]any{},
+		"workspaceFolders": []any{map[string]any{"uri": "file://" + root, "name": "root"}},
+	})
+	test.Expect(t, false, slices.Contains(sent.methods(), "textDocument/publishDiagnostics"))
+
+	h.notify(t, "initialized", map[string]any{})
+	deadline := time.Now().Add(5 * time.Second)
+	for !slices.Contains(sent.methods(), "textDocument/publishDiagnostics") && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	test.Expect(t, true, slices.Contains(sent.methods(), "textDocument/publishDiagnostics"))
+}
+
+func TestHover_ShouldWaitForPendingPlants(t *testing.T) {
+	h, _ := newTestHarvester(t)
+	h.notify(t, "textDocument/didOpen", map[string]any{
+		"textDocument": map[string]any{"uri": "file:///a.vocab", "text": "01/01/2025\n> (it) la casa"},
+	})
+
+	response := h.request(t, "textDocument/hover", map[string]any{
+		"textDocument": map[string]any{"uri": "file:///a.vocab"},
+		"position":     map[string]any{"line": 1, "character": 10},
+	})
+	encoded, _ := json.Marshal(response)
+	decoded := map[string]any{}
+	json.Unmarshal(encoded, &decoded)
+	test.Expect(t, true, decoded["result"] != nil)
+}
+
+// Start a quiz of whatever is due and grade every card of it with grade.
+func answerQuiz(t *testing.T, worker *RequestWorker, grade int) lsproto.QuizAnswer {
+	decode := func(response any, err error, result any) {
+		test.Expect(t, nil, err)
+		encoded, _ := json.Marshal(response)
+		json.Unmarshal(encoded, &struct {
+			Result any `json:"result"`
+		}{Result: result})
+	}
+
+	started := struct {
+		QuizId string `json:"quizId"`
+		Total  int    `json:"total"`
+	}{}
+	response, err := worker.QuizStartWorker(lsproto.RequestMessage{ID: 1, Params: map[string]any{}})
+	decode(response, err, &started)
+	var answer lsproto.QuizAnswer
+	for range started.Total {
+		response, err = worker.QuizAnswerWorker(lsproto.RequestMessage{ID: 2, Params: map[string]any{"quizId": started.QuizId, "grade": grade}})
+		decode(response, err, &answer)
+	}
+	return answer
+}
+
+func TestQuiz_ShouldWriteTheGradesDownUnderTodaysSection(t *testing.T) {
+	f := forest.NewForest(t.Context(), func(any) {}).
+		WithClock(lib.FixedClock{Time: time.Date(2025, time.June, 10, 12, 0, 0, 0, time.Local)})
+	uri := "file:///journal.vocab"
+	f.Plant(uri, "01/06/2025\n> (de) der Hund\n\n10/06/2025\n> (it) il gatto\nIl gatto dorme.\n", nil)
+	f.Harvest()
+	worker := NewRequestWorker(f, nil, lib.NewLogger(io.Discard))
+
+	edit := answerQuiz(t, worker, 4).Edit.Changes[uri][0]
+
+	test.Expect(t, lsproto.Position{Line: 4, Character: 15}, edit.Range.Start)
+	test.Expect(t, "\n>> (de) der Hund(4)", edit.NewText)
+}
+
+func TestQuiz_ShouldTimeTheGradesUnderLearningSteps(t *testing.T) {
+	cfg := config.Default()
+	cfg.Scheduler.LearningSteps = []time.Duration{10 * time.Minute}
+	f := forest.NewForest(t.Context(), func(any) {}).
+		WithClock(lib.FixedClock{Time: time.Date(2025, time.June, 10, 12, 30, 0, 0, time.Local)}).
+		Configure(cfg)
+	uri := "file:///journal.vocab"
+	f.Plant(uri, "01/06/2025\n> (de) der Hund\n\n10/06/2025 09:00\n>> (de) die Katze(1)", nil)
+	f.Harvest()
+	worker := NewRequestWorker(f, nil, lib.NewLogger(io.Discard))
+
+	edit := answerQuiz(t, worker, 4).Edit.Changes[uri][0]
+
+	test.Expect(t, 4, edit.Range.Start.Line)
+	test.Expect(t, "\n10/06/2025 12:30\n>> (de) der Hund(4), die Katze(4)", edit.NewText)
+}
+
+// The text of the document once edit is applied to it.
+func applyEdit(text string, edit lsproto.TextEdit) string {
+	lines := strings.Split(text, "\n")
+	offset := 0
+	for _, line := range lines[:edit.Range.Start.Line] {
+		offset += len(line) + 1
+	}
+	offset += len(string([]rune(lines[edit.Range.Start.Line])[:edit.Range.Start.Character]))
+	return text[:offset] + edit.NewText + text[offset:]
+}
+
+func TestAppendTo_ShouldFenceWhatIsWrittenInMarkdown(t *testing.T) {
+	f := forest.NewForest(t.Context(), func(any) {}).
+		WithClock(lib.FixedClock{Time: time.Date(2025, time.June, 10, 12, 0, 0, 0, time.Local)})
+	uri := "file:///journal.md"
+	text := "# Notes\n\n```vocab\n01/06/2025\n> (de) der Hund\n```\n"
+	f.Plant(uri, text, nil)
+	f.Harvest()
+	worker := NewRequestWorker(f, nil, lib.NewLogger(io.Discard))
+
+	text = applyEdit(text, answerQuiz(t, worker, 4).Edit.Changes[uri][0])
+	response, err := worker.ImportWorker(lsproto.RequestMessage{ID: 3, Params: map[string]any{
+		"uri":     uri,
+		"content": "word,date\ncasa,2025-06-11\n",
+		"columns": map[string]any{"word": "word", "date": "date"},
+		"lang":    "it",
+	}})
+	test.Expect(t, nil, err)
+	encoded, _ := json.Marshal(response)
+	imported := struct {
+		Result lsproto.WorkspaceEdit `json:"result"`
+	}{}
+	json.Unmarshal(encoded, &imported)
+	text = applyEdit(text, imported.Result.Changes[uri][0])
+	f.Plant(uri, text, nil)
+
+	history := f.History()
+	test.Expect(t, 3, len(history))
+	test.Expect(t, "2025-06-10 der Hund", history[1].Date.Format(time.DateOnly)+" "+history[1].Word)
+	test.Expect(t, 4, history[1].Grade)
+	test.Expect(t, "2025-06-11 casa", history[2].Date.Format(time.DateOnly)+" "+history[2].Word)
+}
+
+func TestQuizzes_ShouldDropQuizzesLeftUnansweredForADay(t *testing.T) {
+	quizzes := NewQuizzes()
+	cards := []forest.Card{{Lang: parser.Italiano, Word: "casa", Written: "casa", Uri: "file:///a.vocab"}}
+	morning := time.Date(2025, time.June, 10, 9, 0, 0, 0, time.UTC)
+
+	abandoned := quizzes.Start(cards, lib.Day(morning), false, morning)
+	recent := quizzes.Start(cards, lib.Day(morning), false, morning.Add(20*time.Hour))
+	quizzes.Start(nil, lib.Day(morning), false, morning.Add(25*time.Hour))
+
+	_, err := quizzes.Next(abandoned)
+	test.Expect(t, true, err != nil)
+	_, err = quizzes.Next(recent)
+	test.Expect(t, nil, err)
+}
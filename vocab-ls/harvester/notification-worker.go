@@ -1,28 +1,22 @@
 package harvester
 
 import (
+	"strings"
+	"vocab/config"
 	"vocab/lib"
 	lsproto "vocab/lsp"
 	"vocab/vocabulary/forest"
 )
 
 type NotificationWorker struct {
-	forest *forest.Forest
+	forest    *forest.Forest
+	workspace *Workspace
 }
 
-func diagnosticsToNotificationResponse(uri string, version float64, diags []lsproto.Diagnostic) *lsproto.PublishDiagnosticsNotification {
-	return lsproto.NewPublishDiagnosticsNotfication(
-		lsproto.PublishDiagnosticsParams{
-			Uri:         uri,
-			Version:     version,
-			Diagnostics: diags,
-		},
-	)
-}
-
-func NewNotificationWorker(f *forest.Forest) *NotificationWorker {
+func NewNotificationWorker(f *forest.Forest, workspace *Workspace) *NotificationWorker {
 	return &NotificationWorker{
-		forest: f,
+		forest:    f,
+		workspace: workspace,
 	}
 }
 
@@ -35,6 +29,62 @@ func (n *NotificationWorker) DeleteFileWorker(request lsproto.Notification) (any
 	for _, file := range params.Files {
 		n.forest.Remove(file.Uri)
 	}
+	n.workspace.DiagnosticsChanged()
+
+	return nil, nil
+}
+
+func (n *NotificationWorker) DidChangeWorkspaceFoldersWorker(request lsproto.Notification) (any, error) {
+	params, err := lib.UnmarshalInto(request.Params, &lsproto.DidChangeWorkspaceFoldersParams{})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, folder := range params.Event.Removed {
+		n.workspace.RemoveFolder(folder)
+	}
+	for _, folder := range params.Event.Added {
+		n.workspace.AddFolder(folder)
+	}
+	n.workspace.DiagnosticsChanged()
+
+	return nil, nil
+}
+
+func (n *NotificationWorker) InitializedWorker(request lsproto.Notification) (any, error) {
+	n.workspace.Initialized()
+	return nil, nil
+}
+
+func (n *NotificationWorker) DidChangeConfigurationWorker(request lsproto.Notification) (any, error) {
+	params, err := lib.UnmarshalInto(request.Params, &lsproto.DidChangeConfigurationParams{})
+	if err != nil {
+		return nil, err
+	}
+
+	// Pushed settings are keyed by section, clients using the pull model send null instead.
+	if settings, ok := params.Settings.(map[string]any); ok {
+		if section, ok := settings[config.Section].(map[string]any); ok {
+			n.workspace.SetSettings(section)
+			return nil, nil
+		}
+	}
+	n.workspace.PullSettings()
+
+	return nil, nil
+}
+
+func (n *NotificationWorker) DidChangeWatchedFilesWorker(request lsproto.Notification) (any, error) {
+	params, err := lib.UnmarshalInto(request.Params, &lsproto.DidChangeWatchedFilesParams{})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, change := range params.Changes {
+		if strings.HasSuffix(change.Uri, "/"+config.FileName) {
+			n.workspace.ConfigFileChanged(change.Uri)
+		}
+	}
 
 	return nil, nil
 }
@@ -46,12 +96,9 @@ func (n *NotificationWorker) DidOpenWorker(request lsproto.Notification) (any, e
 	}
 
 	n.forest.Plant(params.TextDocument.Uri, params.TextDocument.Text, nil)
+	n.workspace.DiagnosticsChanged()
 
-	return diagnosticsToNotificationResponse(
-		params.TextDocument.Uri,
-		params.TextDocument.Version,
-		nil,
-	), nil
+	return nil, nil
 }
 
 func (n *NotificationWorker) DidChangeWorker(request lsproto.Notification) (any, error) {
@@ -65,6 +112,7 @@ func (n *NotificationWorker) DidChangeWorker(request lsproto.Notification) (any,
 		// for now, sequential. In the future we can make this parallel
 		n.forest.Plant(params.TextDocument.Uri, change.Text, change.Range)
 	}
+	n.workspace.DiagnosticsChanged()
 
 	return nil, nil
 }
@@ -0,0 +1,98 @@
+package harvester
+
+import (
+	"reflect"
+	"slices"
+	"sync"
+	"time"
+	lsproto "vocab/lsp"
+	"vocab/vocabulary/forest"
+)
+
+// Publisher pushes diagnostics through textDocument/publishDiagnostics for clients that cannot
+// pull them.
+//
+// Changes are batched: the forest is harvested once nothing changed for the debounce window, and
+// only documents whose diagnostics differ from what was last published are sent. Because a word
+// reviewed in one document reschedules it in every other, that can be many more documents than the
+// one that changed.
+type Publisher struct {
+	forest *forest.Forest
+	client Client
+
+	mutex sync.Mutex
+	timer *time.Timer
+	// Map of document uri and the diagnostics last published for it
+	published map[string][]lsproto.Diagnostic
+}
+
+func NewPublisher(f *forest.Forest, client Client) *Publisher {
+	return &Publisher{
+		forest:    f,
+		client:    client,
+		published: make(map[string][]lsproto.Diagnostic),
+	}
+}
+
+// Publish after debounce, unless something else changes before that.
+func (p *Publisher) Schedule(debounce time.Duration) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if p.timer != nil {
+		p.timer.Stop()
+	}
+	p.timer = time.AfterFunc(debounce, p.Flush)
+}
+
+// Harvest now and publish every document whose diagnostics changed.
+func (p *Publisher) Flush() {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if p.client == nil {
+		return
+	}
+
+	harvested := p.forest.Harvest()
+	current := make(map[string][]lsproto.Diagnostic, len(harvested))
+	for uri, diags := range harvested {
+		current[uri] = make([]lsproto.Diagnostic, 0, len(diags))
+		for _, d := range diags {
+			current[uri] = append(current[uri], d.Diagnostic)
+		}
+	}
+
+	// documents that are gone or have nothing left to report
+	for uri := range p.published {
+		if _, exists := current[uri]; !exists {
+			current[uri] = []lsproto.Diagnostic{}
+		}
+	}
+
+	uris := make([]string, 0, len(current))
+	for uri := range current {
+		uris = append(uris, uri)
+	}
+	slices.Sort(uris)
+
+	for _, uri := range uris {
+		diags := current[uri]
+		if previous, exists := p.published[uri]; exists && reflect.DeepEqual(previous, diags) {
+			continue
+		}
+		if _, exists := p.published[uri]; !exists && len(diags) == 0 {
+			continue
+		}
+
+		p.client.Notify("textDocument/publishDiagnostics", lsproto.PublishDiagnosticsParams{
+			Uri:         uri,
+			Diagnostics: diags,
+		})
+		if len(diags) == 0 {
+			delete(p.published, uri)
+		} else {
+			p.published[uri] = diags
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package harvester
+
+import (
+	"fmt"
+	"testing"
+	"time"
+	"vocab/syntax"
+	test "vocab/vocab_testing"
+	"vocab/vocabulary/forest"
+)
+
+func TestPublisher_ShouldOnlyPublishDocumentsThatChanged(t *testing.T) {
+	f := forest.NewForest(t.Context(), func(any) {})
+	client := newFakeClient()
+	publisher := NewPublisher(f, client)
+
+	f.Plant("file:///a.vocab", "01/01/2025\n> (it) mostrare(5)", nil)
+	f.Plant("file:///b.vocab", "01/01/2025\n> (it) casa", nil)
+	f.Plant("file:///c.vocab", "", nil)
+	publisher.Flush()
+	test.Expect(t, 2, len(client.notifications))
+	test.Expect(t, "file:///a.vocab", client.notifications[0].Uri)
+	test.Expect(t, "file:///b.vocab", client.notifications[1].Uri)
+
+	// reviewing the word in c reschedules it in a, which is cleared even though a didn't change
+	today := time.Now().Format(syntax.DateLayout)
+	f.Plant("file:///c.vocab", fmt.Sprintf("%s\n>> (it) mostrare(5)", today), nil)
+	publisher.Flush()
+	test.Expect(t, 3, len(client.notifications))
+	test.Expect(t, "file:///a.vocab", client.notifications[2].Uri)
+	test.Expect(t, 0, len(client.notifications[2].Diagnostics))
+
+	publisher.Flush()
+	test.Expect(t, 3, len(client.notifications))
+
+	f.Remove("file:///b.vocab")
+	publisher.Flush()
+	test.Expect(t, 4, len(client.notifications))
+	test.Expect(t, "file:///b.vocab", client.notifications[3].Uri)
+	test.Expect(t, 0, len(client.notifications[3].Diagnostics))
+}
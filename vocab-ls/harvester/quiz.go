@@ -0,0 +1,165 @@
+package harvester
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	lsproto "vocab/lsp"
+	"vocab/vocabulary/forest"
+	"vocab/vocabulary/parser"
+)
+
+// Quizzes keeps track of the reviews clients go through one card at a time, until their last card
+// is answered.
+type Quizzes struct {
+	mutex sync.Mutex
+	// Id of the latest quiz started
+	last    int
+	quizzes map[string]*quiz
+}
+
+type quiz struct {
+	cards []forest.Card
+	// Grades of the cards answered so far, in order. The next card is the first one left.
+	grades []int
+	// The day the grades are written down for
+	day   time.Time
+	cloze bool
+	// When the quiz was started, to drop it once its client gave up on it
+	started time.Time
+}
+
+// Quizzes still unanswered after this long are dropped, their client went away or moved on.
+const quizLifetime = 24 * time.Hour
+
+func NewQuizzes() *Quizzes {
+	return &Quizzes{quizzes: make(map[string]*quiz)}
+}
+
+// Start a quiz of cards now and return its id, "" if there is nothing to quiz. Quizzes left
+// unanswered for longer than quizLifetime are dropped.
+func (q *Quizzes) Start(cards []forest.Card, day time.Time, cloze bool, now time.Time) string {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	for id, quiz := range q.quizzes {
+		if now.Sub(quiz.started) > quizLifetime {
+			delete(q.quizzes, id)
+		}
+	}
+
+	if len(cards) == 0 {
+		return ""
+	}
+	q.last++
+	id := strconv.Itoa(q.last)
+	q.quizzes[id] = &quiz{cards: cards, day: day, cloze: cloze, started: now}
+	return id
+}
+
+// The card of quiz id to answer next.
+func (q *Quizzes) Next(id string) (lsproto.QuizCard, error) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	quiz, exists := q.quizzes[id]
+	if !exists {
+		return lsproto.QuizCard{}, fmt.Errorf("unknown quiz %s", id)
+	}
+	card := quiz.cards[len(quiz.grades)]
+	next := lsproto.QuizCard{
+		Word:      card.Word,
+		Lang:      card.Lang.Code(),
+		Direction: "recognition",
+		Glosses:   card.Glosses,
+		Remaining: len(quiz.cards) - len(quiz.grades) - 1,
+	}
+	if card.Production {
+		next.Direction = "production"
+	}
+	if quiz.cloze {
+		next.Cloze = card.Cloze
+	}
+	return next, nil
+}
+
+// The grades of a finished quiz, as the reviewed lines it writes down for day.
+type Graded struct {
+	Day time.Time
+	// Map of document uri and its reviewed lines, one per language
+	Lines map[string][]string
+}
+
+// Grade the next card of quiz id. Once every card is answered, the quiz is over and its grades
+// come back.
+func (q *Quizzes) Answer(id string, grade int) (remaining int, graded *Graded, err error) {
+	if grade < 0 || grade > 5 {
+		return 0, nil, fmt.Errorf("expect a grade from 0 to 5, got %d", grade)
+	}
+
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	quiz, exists := q.quizzes[id]
+	if !exists {
+		return 0, nil, fmt.Errorf("unknown quiz %s", id)
+	}
+	quiz.grades = append(quiz.grades, grade)
+	remaining = len(quiz.cards) - len(quiz.grades)
+	if remaining > 0 {
+		return remaining, nil, nil
+	}
+	delete(q.quizzes, id)
+	return 0, &Graded{Day: quiz.day, Lines: quiz.lines()}, nil
+}
+
+// The reviewed lines quiz writes down, by document a word of it was last written in, with a line
+// per language.
+func (quiz *quiz) lines() map[string][]string {
+	type graded struct {
+		card        forest.Card
+		recognition string
+		production  string
+	}
+	// words in the order they were quizzed, both directions of a word under the same grade
+	documents := map[string][]*graded{}
+	for i, card := range quiz.cards {
+		words := documents[card.Uri]
+		index := slices.IndexFunc(words, func(g *graded) bool {
+			return g.card.Lang == card.Lang && g.card.Word == card.Word
+		})
+		if index < 0 {
+			words = append(words, &graded{card: card})
+			index = len(words) - 1
+			documents[card.Uri] = words
+		}
+		if card.Production {
+			words[index].production = strconv.Itoa(quiz.grades[i])
+		} else {
+			words[index].recognition = strconv.Itoa(quiz.grades[i])
+		}
+	}
+
+	lines := map[string][]string{}
+	for uri, words := range documents {
+		for _, lang := range []parser.Language{parser.Deutsch, parser.Français, parser.Italiano} {
+			written := []string{}
+			for _, word := range words {
+				if word.card.Lang != lang {
+					continue
+				}
+				grade := word.recognition
+				if word.card.TwoWay {
+					grade = word.recognition + "/" + word.production
+				}
+				written = append(written, fmt.Sprintf("%s(%s)", word.card.Written, grade))
+			}
+			if len(written) > 0 {
+				lines[uri] = append(lines[uri], fmt.Sprintf(">> (%s) %s", lang.Code(), strings.Join(written, ", ")))
+			}
+		}
+	}
+	return lines
+}
@@ -0,0 +1,74 @@
+package harvester
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"sync"
+	lsproto "vocab/lsp"
+)
+
+// Reports keeps track of the diagnostics the client was last sent for each document, so that
+// documents it already knows about are answered with unchanged reports or left out entirely.
+type Reports struct {
+	mutex sync.Mutex
+	// Map of document uri and the result id last reported for it
+	reported map[string]string
+}
+
+func NewReports() *Reports {
+	return &Reports{reported: make(map[string]string)}
+}
+
+// Identify diags by their content. Equal diagnostics always get the same result id, whatever
+// changed in the document or the schedule to produce them.
+func ResultId(diags []lsproto.Diagnostic) string {
+	hash := fnv.New64a()
+	// only fails on values json can't represent, which a diagnostic never holds
+	encoded, _ := json.Marshal(diags)
+	hash.Write(encoded)
+	return fmt.Sprintf("%016x", hash.Sum64())
+}
+
+// Report diags of documentUri to a client holding previousResultId.
+func (r *Reports) Report(documentUri string, diags []lsproto.Diagnostic, previousResultId string) lsproto.SingleDocumentDiagnosticReport {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	resultId := ResultId(diags)
+	r.reported[documentUri] = resultId
+	if previousResultId == resultId {
+		return lsproto.NewUnchangedReport(resultId)
+	}
+	return lsproto.NewFullReport(resultId, diags)
+}
+
+// Full reports of every document, except skip, whose diagnostics changed since they were last
+// reported. Documents that were reported but are gone get an empty report to clear them.
+func (r *Reports) Changed(documents map[string][]lsproto.Diagnostic, skip string) map[string]lsproto.SingleDocumentDiagnosticReport {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	changed := make(map[string]lsproto.SingleDocumentDiagnosticReport)
+	for uri, diags := range documents {
+		if uri == skip {
+			continue
+		}
+		resultId := ResultId(diags)
+		if r.reported[uri] == resultId {
+			continue
+		}
+		r.reported[uri] = resultId
+		changed[uri] = lsproto.NewFullReport(resultId, diags)
+	}
+
+	for uri := range r.reported {
+		if _, exists := documents[uri]; exists || uri == skip {
+			continue
+		}
+		delete(r.reported, uri)
+		changed[uri] = lsproto.NewFullReport(ResultId(nil), nil)
+	}
+
+	return changed
+}
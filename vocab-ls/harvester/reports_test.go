@@ -0,0 +1,185 @@
+package harvester
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+	"vocab/lib"
+	lsproto "vocab/lsp"
+	test "vocab/vocab_testing"
+	"vocab/vocabulary/forest"
+
+	"github.com/go-json-experiment/json"
+)
+
+func TestReports_ShouldAnswerUnchangedForTheSameDiagnostics(t *testing.T) {
+	reports := NewReports()
+	diags := []lsproto.Diagnostic{*lsproto.MakeDiagnostics("Review now!", 1, 2, 8, lsproto.DiagnosticsSeverityError)}
+
+	first := reports.Report("a", diags, "")
+	test.Expect(t, lsproto.DocumentDiagnosticReportKindFull, first.Kind)
+	test.Expect(t, 1, len(first.Items))
+
+	second := reports.Report("a", diags, first.ResultId)
+	test.Expect(t, lsproto.DocumentDiagnosticReportKindUnchanged, second.Kind)
+	test.Expect(t, first.ResultId, second.ResultId)
+
+	encoded, _ := json.Marshal(second)
+	test.Expect(t, false, strings.Contains(string(encoded), "items"))
+
+	third := reports.Report("a", nil, first.ResultId)
+	test.Expect(t, lsproto.DocumentDiagnosticReportKindFull, third.Kind)
+	encoded, _ = json.Marshal(third)
+	test.Expect(t, true, strings.Contains(string(encoded), `"items":[]`))
+}
+
+func TestReports_ShouldOnlyIncludeRelatedDocumentsThatChanged(t *testing.T) {
+	reports := NewReports()
+	overdue := []lsproto.Diagnostic{*lsproto.MakeDiagnostics("2 days past deadline", 1, 2, 8, lsproto.DiagnosticsSeverityError)}
+	documents := map[string][]lsproto.Diagnostic{"a": {}, "b": overdue, "c": overdue}
+
+	test.Expect(t, 2, len(reports.Changed(documents, "a")))
+	test.Expect(t, 0, len(reports.Changed(documents, "a")))
+
+	documents["b"] = []lsproto.Diagnostic{}
+	delete(documents, "c")
+	changed := reports.Changed(documents, "a")
+	test.Expect(t, 2, len(changed))
+	test.Expect(t, 0, len(changed["b"].Items))
+	// gone, cleared
+	test.Expect(t, 0, len(changed["c"].Items))
+	test.Expect(t, lsproto.DocumentDiagnosticReportKindFull, changed["c"].Kind)
+}
+
+func TestTextDocumentDiagnosticsWorker_ShouldHonorPreviousResultId(t *testing.T) {
+	f := forest.NewForest(t.Context(), func(any) {})
+	f.Plant("file:///a.vocab", "01/01/2025\n> (it) mostrare", nil)
+	f.Plant("file:///b.vocab", "01/01/2025\n> (it) casa", nil)
+	workspace := NewWorkspace(f, nil, lib.NewLogger(os.Stderr))
+	workspace.Initialize(&lsproto.InitializeParams{
+		Capabilities: lsproto.ClientCapabilities{
+			TextDocument: &lsproto.TextDocumentClientCapabilities{
+				Diagnostic: &lsproto.DiagnosticClientCapabilities{RelatedDocumentSupport: true},
+			},
+		},
+	})
+	worker := NewRequestWorker(f, workspace, lib.NewLogger(os.Stderr))
+
+	pull := func(previousResultId string) map[string]any {
+		response, err := worker.TextDocumentDiagnosticsWorker(lsproto.RequestMessage{
+			ID:     1,
+			Method: "textDocument/diagnostic",
+			Params: map[string]any{
+				"textDocument":     map[string]any{"uri": "file:///a.vocab"},
+				"previousResultId": previousResultId,
+			},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		encoded, _ := json.Marshal(response)
+		decoded := map[string]any{}
+		json.Unmarshal(encoded, &decoded)
+		return decoded["result"].(map[string]any)
+	}
+
+	first := pull("")
+	test.Expect(t, "full", first["kind"].(string))
+	test.Expect(t, 1, len(first["relatedDocuments"].(map[string]any)))
+
+	second := pull(first["resultId"].(string))
+	test.Expect(t, "unchanged", second["kind"].(string))
+	test.Expect(t, nil, second["items"])
+	test.Expect(t, nil, second["relatedDocuments"])
+}
+
+func TestWorkspaceDiagnosticsWorker_ShouldReportEveryPlantedDocument(t *testing.T) {
+	f := forest.NewForest(t.Context(), func(any) {})
+	f.Plant("file:///a.vocab", "01/01/2025\n> (it) mostrare", nil)
+	f.Plant("file:///b.vocab", "01/01/2025\n> (it) casa", nil)
+	client := newFakeClient()
+	workspace := NewWorkspace(f, client, lib.NewLogger(os.Stderr))
+	worker := NewRequestWorker(f, workspace, lib.NewLogger(os.Stderr))
+
+	pull := func(params map[string]any) []any {
+		response, err := worker.WorkspaceDiagnosticsWorker(lsproto.RequestMessage{ID: 1, Method: "workspace/diagnostic", Params: params})
+		if err != nil {
+			t.Fatal(err)
+		}
+		encoded, _ := json.Marshal(response)
+		decoded := map[string]any{}
+		json.Unmarshal(encoded, &decoded)
+		return decoded["result"].(map[string]any)["items"].([]any)
+	}
+
+	items := pull(map[string]any{"previousResultIds": []any{
+		map[string]any{"uri": "file:///gone.vocab", "value": "1"},
+	}})
+	test.Expect(t, 3, len(items))
+	a := items[0].(map[string]any)
+	test.Expect(t, "file:///a.vocab", a["uri"].(string))
+	test.Expect(t, "full", a["kind"].(string))
+	test.Expect(t, nil, a["version"])
+	gone := items[2].(map[string]any)
+	test.Expect(t, 0, len(gone["items"].([]any)))
+
+	// streamed, with an up to date result id for a
+	items = pull(map[string]any{
+		"partialResultToken": "token",
+		"previousResultIds": []any{
+			map[string]any{"uri": "file:///a.vocab", "value": a["resultId"].(string)},
+		},
+	})
+	test.Expect(t, 0, len(items))
+	test.Expect(t, 1, len(client.progress))
+	streamed := client.progress[0].Value.(lsproto.WorkspaceDiagnosticReport).Items
+	test.Expect(t, 2, len(streamed))
+	test.Expect(t, lsproto.DocumentDiagnosticReportKindUnchanged, streamed[0].Kind)
+	test.Expect(t, lsproto.DocumentDiagnosticReportKindFull, streamed[1].Kind)
+}
+
+func TestDiagnosticsWorkers_ShouldLeaveReportsAloneAsOfAnotherDay(t *testing.T) {
+	f := forest.NewForest(t.Context(), func(any) {}).
+		WithClock(lib.FixedClock{Time: time.Date(2025, time.January, 2, 12, 0, 0, 0, time.Local)})
+	f.Plant("file:///a.vocab", "01/01/2025\n> (it) mostrare", nil)
+	f.Plant("file:///b.vocab", "01/01/2025\n> (it) casa", nil)
+	workspace := NewWorkspace(f, nil, lib.NewLogger(os.Stderr))
+	workspace.Initialize(&lsproto.InitializeParams{
+		Capabilities: lsproto.ClientCapabilities{
+			TextDocument: &lsproto.TextDocumentClientCapabilities{
+				Diagnostic: &lsproto.DiagnosticClientCapabilities{RelatedDocumentSupport: true},
+			},
+		},
+	})
+	worker := NewRequestWorker(f, workspace, lib.NewLogger(os.Stderr))
+
+	pull := func(asOf string) map[string]any {
+		response, err := worker.TextDocumentDiagnosticsWorker(lsproto.RequestMessage{
+			ID:     1,
+			Method: "textDocument/diagnostic",
+			Params: map[string]any{
+				"textDocument": map[string]any{"uri": "file:///a.vocab"},
+				"asOf":         asOf,
+			},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		encoded, _ := json.Marshal(response)
+		decoded := map[string]any{}
+		json.Unmarshal(encoded, &decoded)
+		return decoded["result"].(map[string]any)
+	}
+
+	test.Expect(t, 1, len(pull("")["relatedDocuments"].(map[string]any)))
+
+	preview := pull("2025-03-01")
+	test.Expect(t, "full", preview["kind"].(string))
+	test.Expect(t, nil, preview["relatedDocuments"])
+	_, err := worker.WorkspaceDiagnosticsWorker(lsproto.RequestMessage{ID: 2, Method: "workspace/diagnostic", Params: map[string]any{"asOf": "2025-03-01"}})
+	test.Expect(t, nil, err)
+
+	// b was reported for today and still is, nothing to send again
+	test.Expect(t, nil, pull("")["relatedDocuments"])
+}
@@ -2,29 +2,38 @@ package harvester
 
 import (
 	"fmt"
-	"io/fs"
 	"maps"
 	"net/url"
-	"os"
 	"path/filepath"
-	"runtime"
+	"regexp"
 	"slices"
 	"strings"
+	"time"
+	"unicode/utf8"
+	"vocab/export"
+	"vocab/importer"
 	"vocab/lib"
 	lsproto "vocab/lsp"
 	"vocab/vocabulary/forest"
+	"vocab/vocabulary/languages"
 	"vocab/vocabulary/parser"
 )
 
 type RequestWorker struct {
-	forest *forest.Forest
-	logger lib.Logger
+	forest    *forest.Forest
+	workspace *Workspace
+	reports   *Reports
+	quizzes   *Quizzes
+	logger    lib.Logger
 }
 
-func NewRequestWorker(f *forest.Forest, logger lib.Logger) *RequestWorker {
+func NewRequestWorker(f *forest.Forest, workspace *Workspace, logger lib.Logger) *RequestWorker {
 	return &RequestWorker{
-		forest: f,
-		logger: logger,
+		forest:    f,
+		workspace: workspace,
+		reports:   NewReports(),
+		quizzes:   NewQuizzes(),
+		logger:    logger,
 	}
 }
 
@@ -34,95 +43,627 @@ func (n *RequestWorker) CollectFromThisFileWorker(rm lsproto.RequestMessage) (an
 		return nil, err
 	}
 
-	harvested := n.forest.Harvest()
+	harvested, err := n.harvest(params.AsOf)
+	if err != nil {
+		return nil, err
+	}
 	thisDocInfo := harvested[params.CurrentDocumentUri]
 
-	itWordSet := make(map[string]struct{})
-	deWordSet := make(map[string]struct{})
+	words, production := newDueWords(), newDueWords()
 	for _, harvested := range thisDocInfo {
 		if harvested.Diagnostic.Severity != lsproto.DiagnosticsSeverityError {
 			continue
 		}
-		if harvested.Lang == parser.Deutsch {
-			_, found := deWordSet[harvested.Word]
-			if found {
-				continue
-			}
-			deWordSet[harvested.Word] = struct{}{}
+		if skipLeech(harvested.Leech, params.IncludeLeeches) {
+			continue
+		}
+		if harvested.Production {
+			production.add(harvested)
 		} else {
-			_, found := itWordSet[harvested.Word]
-			if found {
-				continue
-			}
-			itWordSet[harvested.Word] = struct{}{}
+			words.add(harvested)
 		}
 	}
 
 	return lsproto.NewCollectResponse(rm.ID,
-		slices.Collect(maps.Keys(itWordSet)),
-		slices.Collect(maps.Keys(deWordSet)),
+		words.it(), words.de(),
+		production.it(), production.de(),
 	), nil
 }
 
 func (n *RequestWorker) CollectFromAllFilesWorker(rm lsproto.RequestMessage) (any, error) {
-	harvesteds := n.forest.Harvest()
-	deWordSet := make(map[string]struct{})
-	itWordSet := make(map[string]struct{})
+	params, err := lib.UnmarshalInto(rm.Params, &lsproto.CollectParams{})
+	if err != nil {
+		return nil, err
+	}
+
+	harvesteds, err := n.harvest(params.AsOf)
+	if err != nil {
+		return nil, err
+	}
+	words, production := newDueWords(), newDueWords()
 
 	for _, diagnostics := range harvesteds {
 		for _, harvested := range diagnostics {
 			if harvested.Diagnostic.Severity != lsproto.DiagnosticsSeverityError {
 				continue
 			}
-			if harvested.Lang == parser.Deutsch {
-				_, found := deWordSet[harvested.Word]
-				if found {
-					continue
-				}
-				deWordSet[harvested.Word] = struct{}{}
+			if skipLeech(harvested.Leech, params.IncludeLeeches) {
+				continue
+			}
+			if harvested.Production {
+				production.add(harvested)
 			} else {
-				_, found := itWordSet[harvested.Word]
-				if found {
-					continue
-				}
-				itWordSet[harvested.Word] = struct{}{}
+				words.add(harvested)
 			}
 		}
 	}
 
 	return lsproto.NewCollectResponse(rm.ID,
-		slices.Collect(maps.Keys(itWordSet)),
-		slices.Collect(maps.Keys(deWordSet)),
+		words.it(), words.de(),
+		production.it(), production.de(),
 	), nil
 }
 
+// Whether to leave a due word out of what is collected or quizzed. Leeches are unless the client
+// asks for them: reviewing them once more won't help, they need rewording first.
+func skipLeech(leech bool, includeLeeches bool) bool {
+	return leech && !includeLeeches
+}
+
+// Words due for review, once each, German apart from the rest.
+type dueWords struct {
+	itWordSet map[string]struct{}
+	deWordSet map[string]struct{}
+}
+
+func newDueWords() *dueWords {
+	return &dueWords{
+		itWordSet: make(map[string]struct{}),
+		deWordSet: make(map[string]struct{}),
+	}
+}
+
+func (d *dueWords) add(harvested forest.HarvestedDiagnostic) {
+	if harvested.Lang == parser.Deutsch {
+		d.deWordSet[harvested.Word] = struct{}{}
+	} else {
+		d.itWordSet[harvested.Word] = struct{}{}
+	}
+}
+
+func (d *dueWords) it() []string {
+	return slices.Collect(maps.Keys(d.itWordSet))
+}
+
+func (d *dueWords) de() []string {
+	return slices.Collect(maps.Keys(d.deWordSet))
+}
+
+// Days forecast by vocab/forecast unless the client asks otherwise.
+const defaultForecastDays = 14
+
+func (n *RequestWorker) ForecastWorker(rm lsproto.RequestMessage) (any, error) {
+	params, err := lib.UnmarshalInto(rm.Params, &lsproto.ForecastParams{})
+	if err != nil {
+		return nil, err
+	}
+
+	day, err := n.asOfDay(params.AsOf)
+	if err != nil {
+		return nil, err
+	}
+	days := params.Days
+	if days <= 0 {
+		days = defaultForecastDays
+	}
+
+	forecast := n.forest.Forecast(day, days)
+	return lsproto.NewGenericResponse(rm.ID, map[string]any{
+		"days":      forecastDaysToResult(forecast.Days),
+		"projected": forecastDaysToResult(forecast.Projected),
+	}), nil
+}
+
+func forecastDaysToResult(days []forest.ForecastDay) []map[string]any {
+	count := func(c forest.ForecastCount) map[string]any {
+		return map[string]any{"due": c.Due, "new": c.New, "reviews": c.Reviews}
+	}
+	result := []map[string]any{}
+	for _, day := range days {
+		languages := map[string]any{}
+		for lang, c := range day.Languages {
+			languages[lang] = count(c)
+		}
+		result = append(result, map[string]any{
+			"date":      day.Date.Format(time.DateOnly),
+			"total":     count(day.Total),
+			"languages": languages,
+		})
+	}
+	return result
+}
+
+func (n *RequestWorker) ExportWorker(rm lsproto.RequestMessage) (any, error) {
+	params, err := lib.UnmarshalInto(rm.Params, &lsproto.ExportParams{})
+	if err != nil {
+		return nil, err
+	}
+	format, err := export.ParseFormat(params.Format)
+	if err != nil {
+		return nil, err
+	}
+
+	var content strings.Builder
+	if err := export.Write(&content, format, export.NewRows(n.forest.History(), nil)); err != nil {
+		return nil, err
+	}
+	return lsproto.NewExportResponse(rm.ID, lsproto.ExportResult{
+		Format:  string(format),
+		Content: content.String(),
+	}), nil
+}
+
+func (n *RequestWorker) ImportWorker(rm lsproto.RequestMessage) (any, error) {
+	params, err := lib.UnmarshalInto(rm.Params, &lsproto.ImportParams{})
+	if err != nil {
+		return nil, err
+	}
+
+	mapping := importer.Mapping{
+		Word:            params.Columns.Word,
+		Lang:            params.Columns.Lang,
+		Date:            params.Columns.Date,
+		Grade:           params.Columns.Grade,
+		Ease:            params.Columns.Ease,
+		TwoWay:          params.Columns.TwoWay,
+		ProductionGrade: params.Columns.ProductionGrade,
+		DefaultLang:     params.Lang,
+		DateLayout:      params.DateLayout,
+		Header:          params.Header,
+	}
+	if separator := []rune(params.Separator); len(separator) == 1 {
+		mapping.Separator = separator[0]
+	}
+	entries, err := importer.Read(strings.NewReader(params.Content), mapping)
+	if err != nil {
+		return nil, err
+	}
+	sections := importer.Sections(entries, n.forest.Config().WritingDateLayout())
+
+	return lsproto.NewWorkspaceEditResponse(rm.ID, lsproto.WorkspaceEdit{
+		Changes: map[string][]lsproto.TextEdit{
+			params.Uri: {n.appendTo(params.Uri, sections)},
+		},
+	}), nil
+}
+
+// An edit appending text after whatever the document at uri already has. In Markdown, text gets a
+// ```vocab fence of its own, or it would be read as prose.
+func (n *RequestWorker) appendTo(uri string, text string) lsproto.TextEdit {
+	if parser.IsMarkdown(uri) {
+		text = "```vocab\n" + strings.TrimSuffix(text, "\n") + "\n```\n"
+	}
+	end := lsproto.Position{}
+	if plot, exists := n.forest.Snapshot().Plot(uri); exists && plot.Text != "" {
+		lines := strings.Split(plot.Text, "\n")
+		end = lsproto.Position{Line: len(lines) - 1, Character: utf8.RuneCountInString(lines[len(lines)-1])}
+		if end.Character > 0 {
+			text = "\n" + text
+		}
+	}
+	return lsproto.TextEdit{Range: lsproto.Range{Start: end, End: end}, NewText: text}
+}
+
+func (n *RequestWorker) QuizStartWorker(rm lsproto.RequestMessage) (any, error) {
+	params, err := lib.UnmarshalInto(rm.Params, &lsproto.QuizStartParams{})
+	if err != nil {
+		return nil, err
+	}
+
+	day, err := n.asOfDay(params.AsOf)
+	if err != nil {
+		return nil, err
+	}
+	cards := []forest.Card{}
+	for _, card := range n.forest.Cards(day) {
+		if skipLeech(card.Leech, params.IncludeLeeches) {
+			continue
+		}
+		cards = append(cards, card)
+	}
+
+	return lsproto.NewGenericResponse(rm.ID, map[string]any{
+		"quizId": n.quizzes.Start(cards, day, params.Cloze, n.forest.Snapshot().Now()),
+		"total":  len(cards),
+	}), nil
+}
+
+func (n *RequestWorker) QuizNextWorker(rm lsproto.RequestMessage) (any, error) {
+	params, err := lib.UnmarshalInto(rm.Params, &lsproto.QuizParams{})
+	if err != nil {
+		return nil, err
+	}
+	card, err := n.quizzes.Next(params.QuizId)
+	if err != nil {
+		return nil, err
+	}
+	return lsproto.NewQuizCardResponse(rm.ID, card), nil
+}
+
+func (n *RequestWorker) QuizAnswerWorker(rm lsproto.RequestMessage) (any, error) {
+	params, err := lib.UnmarshalInto(rm.Params, &lsproto.QuizAnswerParams{})
+	if err != nil {
+		return nil, err
+	}
+	remaining, graded, err := n.quizzes.Answer(params.QuizId, params.Grade)
+	if err != nil {
+		return nil, err
+	}
+
+	answer := lsproto.QuizAnswer{Remaining: remaining}
+	if graded != nil {
+		edit := lsproto.WorkspaceEdit{Changes: map[string][]lsproto.TextEdit{}}
+		for uri, lines := range graded.Lines {
+			edit.Changes[uri] = []lsproto.TextEdit{n.writeReviewed(uri, graded.Day, lines)}
+		}
+		answer.Edit = &edit
+	}
+	return lsproto.NewQuizAnswerResponse(rm.ID, answer), nil
+}
+
+// An edit writing the reviewed lines of day down in the document at uri, under the last section
+// of day if it has one, else in a new section at its end.
+//
+// Learning steps count minutes, so today's reviews then get a section of their own, timed now.
+func (n *RequestWorker) writeReviewed(uri string, day time.Time, lines []string) lsproto.TextEdit {
+	snapshot := n.forest.Snapshot()
+	cfg := n.forest.Config()
+	reviewed := strings.Join(lines, "\n")
+	header := day.Format(cfg.WritingDateLayout())
+	if len(cfg.Parameters().LearningSteps) > 0 && day.Equal(snapshot.Today()) {
+		return n.appendTo(uri, header+snapshot.Now().Format(" 15:04")+"\n"+reviewed)
+	}
+
+	plot, exists := snapshot.Plot(uri)
+	if !exists || plot.SectionOn(day) == nil {
+		return n.appendTo(uri, header+"\n"+reviewed)
+	}
+	section := plot.SectionOn(day)
+	last := section.Date.Line
+	for _, words := range slices.Concat(section.NewWords, section.ReviewedWords) {
+		last = max(last, words.Line)
+	}
+	text := strings.TrimSuffix(strings.Split(plot.Text, "\n")[last], "\r")
+	end := lsproto.Position{Line: last, Character: utf8.RuneCountInString(text)}
+	return lsproto.TextEdit{Range: lsproto.Range{Start: end, End: end}, NewText: "\n" + reviewed}
+}
+
+func (n *RequestWorker) LeechesWorker(rm lsproto.RequestMessage) (any, error) {
+	return lsproto.NewLeechesResponse(rm.ID, n.forest.Leeches()), nil
+}
+
+func (n *RequestWorker) ClozeWorker(rm lsproto.RequestMessage) (any, error) {
+	params, err := lib.UnmarshalInto(rm.Params, &lsproto.ClozeParams{})
+	if err != nil {
+		return nil, err
+	}
+
+	day, err := n.asOfDay(params.AsOf)
+	if err != nil {
+		return nil, err
+	}
+	return lsproto.NewClozeResponse(rm.ID, n.forest.Clozes(day)), nil
+}
+
+func (n *RequestWorker) HoverWorker(rm lsproto.RequestMessage) (any, error) {
+	params, err := lib.UnmarshalInto(rm.Params, &lsproto.HoverParams{})
+	if err != nil {
+		return nil, err
+	}
+
+	// the word may have just been typed
+	snapshot := n.forest.Settled()
+	description, found := snapshot.Pick(params.TextDocument.Uri, params.Position.Line, params.Position.Character)
+	if !found {
+		return lsproto.NewNullResponse(rm.ID), nil
+	}
+	fruit, _ := snapshot.PickFruit(params.TextDocument.Uri, params.Position.Line, params.Position.Character)
+	entry, found, err := n.workspace.Dictionaries().Lookup(fruit.Lang.Code(), fruit.Text)
+	if err != nil {
+		n.logger.Logf("Can't look %s up: %v", fruit.Text, err)
+	}
+	if found {
+		description = entry.Word + "\n" + entry.Definition + "\n\n" + description
+	}
+	return lsproto.NewTextDocumentHoverResponse(rm.ID, description, nil), nil
+}
+
+// Dictionary entries offered by textDocument/completion, at most.
+const completionLimit = 50
+
+// The language and the word being written at the end of line, after `> (it)` and any words before
+// it.
+var wordBeingWritten = regexp.MustCompile(`^\s*>>?\s*\(([a-z]+)\)(?:.*,)?\s*([^,()|=:` + "`" + `]*)$`)
+
+// Complete the word being written in a words section with the entries of the dictionary of its
+// language.
+func (n *RequestWorker) CompletionWorker(rm lsproto.RequestMessage) (any, error) {
+	params, err := lib.UnmarshalInto(rm.Params, &lsproto.CompletionParams{})
+	if err != nil {
+		return nil, err
+	}
+
+	list := lsproto.CompletionList{Items: []lsproto.CompletionItem{}}
+	plot, exists := n.forest.Settled().Plot(params.TextDocument.Uri)
+	if !exists {
+		return lsproto.NewCompletionResponse(rm.ID, list), nil
+	}
+	lines := strings.Split(plot.Text, "\n")
+	if params.Position.Line >= len(lines) {
+		return lsproto.NewCompletionResponse(rm.ID, list), nil
+	}
+	line := []rune(strings.TrimSuffix(lines[params.Position.Line], "\r"))
+	match := wordBeingWritten.FindStringSubmatch(string(line[:min(params.Position.Character, len(line))]))
+	if match == nil {
+		return lsproto.NewCompletionResponse(rm.ID, list), nil
+	}
+
+	lang := parser.LanguageOf(match[1])
+	// complete the word the way it is scheduled, without its article
+	prefix := forest.NewWordTree().GetNormalizedText(lang, &parser.Word{Text: strings.TrimSpace(match[2])})
+	if prefix == "" {
+		// every word of the dictionary would do, wait for a letter
+		list.IsIncomplete = true
+		return lsproto.NewCompletionResponse(rm.ID, list), nil
+	}
+	entries, err := n.workspace.Dictionaries().Complete(match[1], prefix, completionLimit)
+	if err != nil {
+		n.logger.Logf("Can't complete %s: %v", prefix, err)
+	}
+	for _, entry := range entries {
+		detail, _, _ := strings.Cut(entry.Definition, "\n")
+		list.Items = append(list.Items, lsproto.CompletionItem{
+			Label:         entry.Word,
+			Kind:          lsproto.CompletionItemKindText,
+			Detail:        detail,
+			Documentation: entry.Definition,
+		})
+	}
+	list.IsIncomplete = len(entries) == completionLimit
+	return lsproto.NewCompletionResponse(rm.ID, list), nil
+}
+
+// Offer to write every inflected form within the range in one of its citation forms instead.
+func (n *RequestWorker) CodeActionWorker(rm lsproto.RequestMessage) (any, error) {
+	params, err := lib.UnmarshalInto(rm.Params, &lsproto.CodeActionParams{})
+	if err != nil {
+		return nil, err
+	}
+
+	actions := []lsproto.CodeAction{}
+	for _, inflection := range n.forest.Snapshot().Inflections(params.TextDocument.Uri) {
+		word := inflection.Word
+		if word.Line < params.Range.Start.Line || word.Line > params.Range.End.Line {
+			continue
+		}
+		// the warning sits on the whole word, article included
+		resolved := []lsproto.Diagnostic{}
+		for _, diag := range params.Context.Diagnostics {
+			if diag.Code == forest.LemmaCode && diag.Range.Start.Line == word.Line && diag.Range.Start.Character == word.Start {
+				resolved = append(resolved, diag)
+			}
+		}
+		for i, lemma := range inflection.Lemmas {
+			actions = append(actions, lsproto.CodeAction{
+				Title:       fmt.Sprintf("Write %s instead", lemma),
+				Kind:        lsproto.CodeActionKindQuickFix,
+				Diagnostics: resolved,
+				IsPreferred: i == 0,
+				Edit: lsproto.WorkspaceEdit{
+					Changes: map[string][]lsproto.TextEdit{
+						params.TextDocument.Uri: {{Range: inflection.Range, NewText: lemma}},
+					},
+				},
+			})
+		}
+	}
+	return lsproto.NewCodeActionResponse(rm.ID, actions), nil
+}
+
+// Legend of textDocument/semanticTokens: words written with a telling article are nouns, their
+// gender a modifier. Phrases are marked along with the words of the utterances they are used in.
+var (
+	semanticTokenTypes     = []string{"noun", "phrase"}
+	semanticTokenModifiers = []languages.Gender{languages.Masculine, languages.Feminine, languages.Neuter}
+)
+
+// Mark every noun of the document with its gender and every phrase, so that themes can color them
+// apart.
+func (n *RequestWorker) SemanticTokensWorker(rm lsproto.RequestMessage) (any, error) {
+	params, err := lib.UnmarshalInto(rm.Params, &lsproto.SemanticTokensParams{})
+	if err != nil {
+		return nil, err
+	}
+
+	type token struct {
+		at        lsproto.Range
+		kind      int
+		modifiers int
+	}
+	marked := []token{}
+	snapshot := n.forest.Snapshot()
+	for _, noun := range snapshot.Nouns(params.TextDocument.Uri) {
+		marked = append(marked, token{wordRange(noun.Word), 0, 1 << slices.Index(semanticTokenModifiers, noun.Gender)})
+	}
+	for _, phrase := range snapshot.Phrases(params.TextDocument.Uri) {
+		marked = append(marked, token{wordRange(phrase.Word), 1, 0})
+		for _, match := range phrase.Matches {
+			marked = append(marked, token{match, 1, 0})
+		}
+	}
+	slices.SortFunc(marked, func(a, b token) int {
+		if a.at.Start.Line != b.at.Start.Line {
+			return a.at.Start.Line - b.at.Start.Line
+		}
+		return a.at.Start.Character - b.at.Start.Character
+	})
+
+	tokens := lsproto.SemanticTokens{Data: []int{}}
+	line, start := 0, 0
+	for i, t := range marked {
+		// a word used by two phrases of the same section is marked once
+		if i > 0 && t.at == marked[i-1].at {
+			continue
+		}
+		if t.at.Start.Line != line {
+			start = 0
+		}
+		tokens.Data = append(tokens.Data, t.at.Start.Line-line, t.at.Start.Character-start, t.at.End.Character-t.at.Start.Character, t.kind, t.modifiers)
+		line, start = t.at.Start.Line, t.at.Start.Character
+	}
+	return lsproto.NewSemanticTokensResponse(rm.ID, tokens), nil
+}
+
+func wordRange(word *parser.Word) lsproto.Range {
+	return lsproto.Range{
+		Start: lsproto.Position{Line: word.Line, Character: word.Start},
+		End:   lsproto.Position{Line: word.Line, Character: word.End},
+	}
+}
+
+// Days covered by the heatmap of vocab/stats unless the client asks otherwise.
+const defaultHeatmapDays = 365
+
+func (n *RequestWorker) StatsWorker(rm lsproto.RequestMessage) (any, error) {
+	params, err := lib.UnmarshalInto(rm.Params, &lsproto.StatsParams{})
+	if err != nil {
+		return nil, err
+	}
+
+	day, err := n.asOfDay(params.AsOf)
+	if err != nil {
+		return nil, err
+	}
+	heatmapDays := params.HeatmapDays
+	if heatmapDays <= 0 {
+		heatmapDays = defaultHeatmapDays
+	}
+
+	return lsproto.NewStatsResponse(rm.ID, n.forest.Stats(day, heatmapDays)), nil
+}
+
+// The day asOf, a yyyy-mm-dd date, stands for, today if empty.
+func (n *RequestWorker) asOfDay(asOf string) (time.Time, error) {
+	if asOf == "" {
+		return n.forest.Snapshot().Today(), nil
+	}
+	day, err := time.Parse(time.DateOnly, asOf)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("expect asOf to be a yyyy-mm-dd date: %w", err)
+	}
+	return day, nil
+}
+
+// Harvest as of asOf, a yyyy-mm-dd date, or today if empty.
+func (n *RequestWorker) harvest(asOf string) (map[string][]forest.HarvestedDiagnostic, error) {
+	if asOf == "" {
+		return n.forest.Harvest(), nil
+	}
+	day, err := n.asOfDay(asOf)
+	if err != nil {
+		return nil, err
+	}
+	return n.forest.HarvestOn(day), nil
+}
+
 func (n *RequestWorker) TextDocumentDiagnosticsWorker(message lsproto.RequestMessage) (any, error) {
 	request, err := lib.UnmarshalInto(message.Params, &lsproto.DocumentDiagnosticsParams{})
 	if err != nil {
 		return nil, err
 	}
 
-	diagnostics := n.forest.Harvest()
-	var thisDocDiags []lsproto.Diagnostic
-	for _, d := range diagnostics[request.TextDocument.Uri] {
-		thisDocDiags = append(thisDocDiags, d.Diagnostic)
+	harvesteds, err := n.harvest(request.AsOf)
+	if err != nil {
+		return nil, err
+	}
+	documents := make(map[string][]lsproto.Diagnostic)
+	for uri, harvested := range harvesteds {
+		documents[uri] = make([]lsproto.Diagnostic, 0, len(harvested))
+		for _, d := range harvested {
+			documents[uri] = append(documents[uri], d.Diagnostic)
+		}
+	}
+
+	uri := request.TextDocument.Uri
+	if request.AsOf != "" {
+		// a preview of another day, what the client holds for today stays as it was reported
+		return lsproto.NewDocumentDiagnosticResponse(message.ID, lsproto.NewFullReport(ResultId(documents[uri]), documents[uri]), nil), nil
+	}
+	report := n.reports.Report(uri, documents[uri], request.PreviousResultId)
+	// a change of date in one vocab can affect another, those are sent along if the client takes them
+	var related map[string]lsproto.SingleDocumentDiagnosticReport
+	if n.workspace.RelatedDocumentSupport() {
+		related = n.reports.Changed(documents, uri)
+	}
+
+	return lsproto.NewDocumentDiagnosticResponse(message.ID, report, related), nil
+}
+
+// How many documents go in each $/progress notification when streaming workspace diagnostics.
+const workspaceDiagnosticsBatchSize = 100
+
+// Report every planted document, including those never opened.
+//
+// Documents the client holds an up to date result id for are reported unchanged. If the client
+// asks for partial results, reports are streamed in batches and the response itself is empty.
+func (n *RequestWorker) WorkspaceDiagnosticsWorker(message lsproto.RequestMessage) (any, error) {
+	request, err := lib.UnmarshalInto(message.Params, &lsproto.WorkspaceDiagnosticParams{})
+	if err != nil {
+		return nil, err
+	}
+
+	previousResultIds := make(map[string]string)
+	for _, previous := range request.PreviousResultIds {
+		previousResultIds[previous.Uri] = previous.Value
+	}
+
+	harvesteds, err := n.harvest(request.AsOf)
+	if err != nil {
+		return nil, err
+	}
+	documents := make(map[string][]lsproto.Diagnostic)
+	for uri, harvested := range harvesteds {
+		documents[uri] = make([]lsproto.Diagnostic, 0, len(harvested))
+		for _, d := range harvested {
+			documents[uri] = append(documents[uri], d.Diagnostic)
+		}
+	}
+	// the client still lists documents that are gone, clear them
+	for uri := range previousResultIds {
+		if _, exists := documents[uri]; !exists {
+			documents[uri] = []lsproto.Diagnostic{}
+		}
 	}
 
-	delete(diagnostics, request.TextDocument.Uri)
-	restDiags := make(map[string][]lsproto.Diagnostic)
-	for key := range diagnostics {
-		for _, d := range diagnostics[key] {
-			restDiags[key] = append(restDiags[key], d.Diagnostic)
+	uris := slices.Sorted(maps.Keys(documents))
+	items := make([]lsproto.WorkspaceDocumentDiagnosticReport, 0, len(uris))
+	for _, uri := range uris {
+		report := lsproto.NewFullReport(ResultId(documents[uri]), documents[uri])
+		// previews of another day are left out of what the client was reported
+		if request.AsOf == "" {
+			report = n.reports.Report(uri, documents[uri], previousResultIds[uri])
 		}
+		items = append(items, lsproto.NewWorkspaceDocumentDiagnosticReport(uri, report))
 	}
 
-	response := lsproto.NewFullDocumentDiagnosticResponse(
-		message.ID,
-		thisDocDiags,
-		restDiags,
-	)
+	if request.PartialResultToken == nil {
+		return lsproto.NewWorkspaceDiagnosticResponse(message.ID, items), nil
+	}
 
-	return response, nil
+	for batch := range slices.Chunk(items, workspaceDiagnosticsBatchSize) {
+		n.workspace.ReportProgress(request.PartialResultToken, lsproto.WorkspaceDiagnosticReport{Items: batch})
+	}
+	return lsproto.NewWorkspaceDiagnosticResponse(message.ID, nil), nil
 }
 
 func TransformWindowsPathToLspUri(path string) string {
@@ -140,66 +681,65 @@ func TransformWindowsPathToLspUri(path string) string {
 }
 
 func (n *RequestWorker) InitializeWorker(message lsproto.RequestMessage) (any, error) {
-	root := message.Params["rootPath"].(string)
-	filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
-		isFile := d.Type().IsRegular()
-		isVocab := func() bool {
-			chunks := strings.Split(d.Name(), ".")
-			extension := chunks[len(chunks)-1]
-			return extension == "vocab"
-		}()
-
-		if !isFile || !isVocab {
-			return nil
-		}
-
-		bytes, readErr := os.ReadFile(path)
-		if readErr != nil {
-			n.logger.Logf("Can't read content at %s", root)
-		}
-		fileContent := string(bytes)
-		fileUri := func() string {
-			if runtime.GOOS != "windows" {
-				return fmt.Sprintf("%s%s", "file://", path)
-			}
-
-			return TransformWindowsPathToLspUri(path)
-		}()
+	params, err := lib.UnmarshalInto(message.Params, &lsproto.InitializeParams{})
+	if err != nil {
+		return nil, err
+	}
 
-		n.forest.Plant(fileUri, fileContent, nil)
+	n.workspace.Initialize(params)
 
-		return nil
-	})
+	capabilities := map[string]any{
+		// https://microsoft.github.io/language-server-protocol/specifications/lsp/3.17/specification/#serverCapabilities
+		"textDocumentSync": map[string]any{
+			"openClose": true,
+			"change":    lsproto.TextDocumentSyncKindFull,
+		},
+		"hoverProvider": true,
+		"completionProvider": map[string]any{
+			"triggerCharacters": []string{" "},
+		},
+		"codeActionProvider": map[string]any{
+			"codeActionKinds": []string{lsproto.CodeActionKindQuickFix},
+		},
+		"semanticTokensProvider": map[string]any{
+			"legend": map[string]any{
+				"tokenTypes":     semanticTokenTypes,
+				"tokenModifiers": semanticTokenModifiers,
+			},
+			"full": true,
+		},
+		// https://microsoft.github.io/language-server-protocol/specifications/lsp/3.17/specification/#workspace_didChangeWatchedFiles
+		"workspace": map[string]any{
+			"workspaceFolders": map[string]any{
+				"supported":           true,
+				"changeNotifications": true,
+			},
+			"fileOperations": map[string]any{
+				"didDelete": map[string]any{
+					"filters": []map[string]any{
+						{
+							"scheme":  "file",
+							"pattern": map[string]any{"glob": n.workspace.PlantableGlob()},
+						},
+					},
+				},
+			},
+		},
+	}
+	// Clients that can't pull get their diagnostics pushed instead.
+	if n.workspace.PullsDiagnostics() {
+		capabilities["diagnosticProvider"] = map[string]any{
+			// a change of date in one vocab can affect another (spaced repetition)
+			"interFileDependencies": true,
+			"workspaceDiagnostics":  true,
+		}
+	}
 
 	response := map[string]any{
 		"jsonrpc": "2.0",
 		"id":      message.ID, // echo the request id
 		"result": map[string]any{
-			"capabilities": map[string]any{
-				// https://microsoft.github.io/language-server-protocol/specifications/lsp/3.17/specification/#serverCapabilities
-				"textDocumentSync": map[string]any{
-					"openClose": true,
-					"change":    lsproto.TextDocumentSyncKindFull,
-				},
-				// "hoverProvider": true,
-				"diagnosticProvider": map[string]any{
-					// a change of date in one vocab can affect another (spaced repetition)
-					"interFileDependencies": true,
-				},
-				// https://microsoft.github.io/language-server-protocol/specifications/lsp/3.17/specification/#workspace_didChangeWatchedFiles
-				"workspace": map[string]any{
-					"fileOperations": map[string]any{
-						"didDelete": map[string]any{
-							"filters": []map[string]any{
-								{
-									"scheme":  "file",
-									"pattern": map[string]any{"glob": "**/*.vocab"},
-								},
-							},
-						},
-					},
-				},
-			},
+			"capabilities": capabilities,
 			// optional, helps debugging in client logs
 			"serverInfo": map[string]any{
 				"name":    "vocab-ls",
@@ -1,8 +1,17 @@
 package harvester
 
 import (
+	"encoding/json"
+	"os"
+	"path/filepath"
 	"runtime"
+	"strings"
 	"testing"
+	"time"
+	"vocab/lib"
+	lsproto "vocab/lsp"
+	test "vocab/vocab_testing"
+	"vocab/vocabulary/forest"
 )
 
 func TestTransformWindowsPathToLspUri(t *testing.T) {
@@ -18,3 +27,192 @@ func TestTransformWindowsPathToLspUri(t *testing.T) {
 		t.Fatalf("Invalid file transform. Expected '%s', got '%s'", expect, result)
 	}
 }
+
+func TestHoverAndCompletion_ShouldShowDictionaryEntries(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		return
+	}
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "it.tsv"), []byte("casa\thouse\ncasale\tfarmhouse\ncane\tdog\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, ".vocabrc"), []byte("[dictionaries]\nit = \"it.tsv\"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	text := "01/01/2025\n> (it) la casa, la cas"
+	if err := os.WriteFile(filepath.Join(root, "it.vocab"), []byte(text), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	f := forest.NewForest(t.Context(), func(any) {})
+	workspace := NewWorkspace(f, nil, lib.NewLogger(os.Stderr))
+	workspace.Initialize(&lsproto.InitializeParams{
+		WorkspaceFolders: []lsproto.WorkspaceFolder{{Uri: "file://" + root, Name: "root"}},
+	})
+	defer workspace.Dictionaries().Close()
+	f.Harvest()
+	worker := NewRequestWorker(f, workspace, lib.NewLogger(os.Stderr))
+
+	request := func(run func(lsproto.RequestMessage) (any, error), character int) map[string]any {
+		response, err := run(lsproto.RequestMessage{
+			ID: 1,
+			Params: map[string]any{
+				"textDocument": map[string]any{"uri": "file://" + root + "/it.vocab"},
+				"position":     map[string]any{"line": 1, "character": character},
+			},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		encoded, _ := json.Marshal(response)
+		decoded := map[string]any{}
+		json.Unmarshal(encoded, &decoded)
+		return decoded["result"].(map[string]any)
+	}
+
+	hover := request(worker.HoverWorker, 10)
+	value := hover["contents"].(map[string]any)["value"].(string)
+	test.Expect(t, true, strings.HasPrefix(value, "casa\nhouse\n\n"))
+
+	completion := request(worker.CompletionWorker, len("> (it) la casa, la cas"))
+	items := completion["items"].([]any)
+	test.Expect(t, 2, len(items))
+	test.Expect(t, "casa", items[0].(map[string]any)["label"].(string))
+	test.Expect(t, "farmhouse", items[1].(map[string]any)["detail"].(string))
+}
+
+func TestSemanticTokens_ShouldMarkNounsWithTheirGender(t *testing.T) {
+	f := forest.NewForest(t.Context(), func(any) {})
+	f.Plant("file:///a.vocab", "01/01/2025\n> (de) der Berg, Haus, die Katze\n>> (it) la casa", nil)
+	f.Harvest()
+	worker := NewRequestWorker(f, NewWorkspace(f, nil, lib.NewLogger(os.Stderr)), lib.NewLogger(os.Stderr))
+
+	response, err := worker.SemanticTokensWorker(lsproto.RequestMessage{
+		ID:     1,
+		Params: map[string]any{"textDocument": map[string]any{"uri": "file:///a.vocab"}},
+	})
+
+	test.Expect(t, nil, err)
+	encoded, _ := json.Marshal(response)
+	decoded := struct {
+		Result lsproto.SemanticTokens `json:"result"`
+	}{}
+	json.Unmarshal(encoded, &decoded)
+	expect := []int{
+		1, 7, 8, 0, 1, // der Berg, masculine
+		0, 16, 9, 0, 2, // die Katze, feminine
+		1, 8, 7, 0, 2, // la casa, feminine
+	}
+	test.Expect(t, len(expect), len(decoded.Result.Data))
+	for i := range expect {
+		test.Expect(t, expect[i], decoded.Result.Data[i])
+	}
+}
+
+func TestCodeAction_ShouldWriteTheCitationForm(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		return
+	}
+
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "it.txt"), []byte("entrambi\tentrambe\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, ".vocabrc"), []byte("[lemmas]\nit = \"it.txt\"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "it.vocab"), []byte("18/09/2025\n> (it) entrambe"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	f := forest.NewForest(t.Context(), func(any) {})
+	workspace := NewWorkspace(f, nil, lib.NewLogger(os.Stderr))
+	workspace.Initialize(&lsproto.InitializeParams{
+		WorkspaceFolders: []lsproto.WorkspaceFolder{{Uri: "file://" + root, Name: "root"}},
+	})
+	defer workspace.Dictionaries().Close()
+	f.Harvest()
+	worker := NewRequestWorker(f, workspace, lib.NewLogger(os.Stderr))
+
+	uri := "file://" + root + "/it.vocab"
+	warning := lsproto.MakeDiagnostics("entrambe is an inflected form, write entrambi instead", 1, 7, 15, lsproto.DiagnosticsSeverityWarning)
+	warning.Code = forest.LemmaCode
+	response, err := worker.CodeActionWorker(lsproto.RequestMessage{
+		ID: 1,
+		Params: map[string]any{
+			"textDocument": map[string]any{"uri": uri},
+			"range":        map[string]any{"start": map[string]any{"line": 1, "character": 9}, "end": map[string]any{"line": 1, "character": 9}},
+			"context":      map[string]any{"diagnostics": []any{warning}},
+		},
+	})
+
+	test.Expect(t, nil, err)
+	encoded, _ := json.Marshal(response)
+	decoded := struct {
+		Result []lsproto.CodeAction `json:"result"`
+	}{}
+	json.Unmarshal(encoded, &decoded)
+	test.Expect(t, 1, len(decoded.Result))
+	test.Expect(t, "Write entrambi instead", decoded.Result[0].Title)
+	test.Expect(t, 1, len(decoded.Result[0].Diagnostics))
+	edit := decoded.Result[0].Edit.Changes[uri][0]
+	test.Expect(t, "entrambi", edit.NewText)
+	test.Expect(t, 7, edit.Range.Start.Character)
+	test.Expect(t, 15, edit.Range.End.Character)
+}
+
+func TestQuiz_ShouldWriteTheGradesDownOnceEveryCardIsAnswered(t *testing.T) {
+	f := forest.NewForest(t.Context(), func(any) {}).
+		WithClock(lib.FixedClock{Time: time.Date(2025, time.June, 10, 12, 0, 0, 0, time.Local)})
+	uri := "file:///journal.vocab"
+	f.Plant(uri, "01/06/2025\n> (de) der Hund(5/1), `das Haus`\n> (it) la casa\nLa casa è grande.", nil)
+	f.Harvest()
+	worker := NewRequestWorker(f, nil, lib.NewLogger(os.Stderr))
+
+	decode := func(response any, err error, result any) {
+		test.Expect(t, nil, err)
+		encoded, _ := json.Marshal(response)
+		json.Unmarshal(encoded, &struct {
+			Result any `json:"result"`
+		}{Result: result})
+	}
+
+	started := struct {
+		QuizId string `json:"quizId"`
+		Total  int    `json:"total"`
+	}{}
+	response, err := worker.QuizStartWorker(lsproto.RequestMessage{ID: 1, Params: map[string]any{"cloze": true}})
+	decode(response, err, &started)
+	test.Expect(t, 4, started.Total)
+
+	quiz := map[string]any{"quizId": started.QuizId}
+	directions := []string{}
+	var answer lsproto.QuizAnswer
+	for i, grade := range []int{4, 2, 3, 5} {
+		card := lsproto.QuizCard{}
+		response, err = worker.QuizNextWorker(lsproto.RequestMessage{ID: 2, Params: quiz})
+		decode(response, err, &card)
+		test.Expect(t, 3-i, card.Remaining)
+		directions = append(directions, card.Word+" "+card.Direction)
+		if card.Word == "casa" {
+			test.Expect(t, "La ____ è grande.", card.Cloze.Sentence)
+		}
+
+		response, err = worker.QuizAnswerWorker(lsproto.RequestMessage{ID: 3, Params: map[string]any{"quizId": started.QuizId, "grade": grade}})
+		decode(response, err, &answer)
+		test.Expect(t, 3-i, answer.Remaining)
+	}
+
+	test.Expect(t, "Hund recognition, Hund production, das Haus recognition, casa recognition", strings.Join(directions, ", "))
+	edit := answer.Edit.Changes[uri][0]
+	test.Expect(t, 3, edit.Range.Start.Line)
+	test.Expect(t, "\n10/06/2025\n>> (de) der Hund(4/2), `das Haus`(3)\n>> (it) la casa(5)", edit.NewText)
+
+	// the quiz is over
+	_, err = worker.QuizNextWorker(lsproto.RequestMessage{ID: 4, Params: quiz})
+	test.Expect(t, true, err != nil)
+	_, err = worker.QuizAnswerWorker(lsproto.RequestMessage{ID: 5, Params: map[string]any{"quizId": started.QuizId, "grade": 7}})
+	test.Expect(t, true, err != nil)
+}
@@ -0,0 +1,384 @@
+package harvester
+
+import (
+	"fmt"
+	"io/fs"
+	"maps"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"vocab/config"
+	"vocab/dictionary"
+	"vocab/lib"
+	lsproto "vocab/lsp"
+	"vocab/vocabulary/forest"
+	"vocab/vocabulary/parser"
+)
+
+// Workspace keeps track of the folders opened by the client, plants every vocab file in them and
+// resolves the configuration that applies to the forest.
+type Workspace struct {
+	forest    *forest.Forest
+	client    Client
+	logger    lib.Logger
+	publisher *Publisher
+
+	capabilities lsproto.ClientCapabilities
+	// Set once the client is ready to hear from us, capabilities are set by then
+	initialized atomic.Bool
+	folders     []lsproto.WorkspaceFolder
+	// Folder uris or names that keep their own schedule
+	independentSchedules []string
+
+	// Map of folder uri and its parsed .vocabrc, if any
+	configFiles map[string]*config.Source
+	// Settings sent by the client
+	settings *config.Source
+	config   config.Config
+	// Dictionaries of the configured languages
+	dictionaries *dictionary.Library
+}
+
+func NewWorkspace(f *forest.Forest, client Client, logger lib.Logger) *Workspace {
+	return &Workspace{
+		forest:       f,
+		client:       client,
+		logger:       logger,
+		publisher:    NewPublisher(f, client),
+		folders:      []lsproto.WorkspaceFolder{},
+		configFiles:  make(map[string]*config.Source),
+		config:       config.Default(),
+		dictionaries: dictionary.NewLibrary(dictionary.DefaultCacheDir()),
+	}
+}
+
+// Resolve the workspace folders of the initialize request.
+//
+// workspaceFolders wins over rootUri, which wins over the deprecated rootPath.
+// Any of them can be null.
+func (w *Workspace) Initialize(params *lsproto.InitializeParams) {
+	w.capabilities = params.Capabilities
+	if params.InitializationOptions != nil {
+		w.independentSchedules = params.InitializationOptions.IndependentSchedules
+	}
+
+	folders := params.WorkspaceFolders
+	if len(folders) == 0 && params.RootUri != nil && *params.RootUri != "" {
+		folders = []lsproto.WorkspaceFolder{{Uri: *params.RootUri, Name: filepath.Base(lib.UriToPath(*params.RootUri))}}
+	}
+	if len(folders) == 0 && params.RootPath != nil && *params.RootPath != "" {
+		folders = []lsproto.WorkspaceFolder{{Uri: PathToLspUri(*params.RootPath), Name: filepath.Base(*params.RootPath)}}
+	}
+
+	for _, folder := range folders {
+		w.AddFolder(folder)
+	}
+}
+
+// Ask the client for its settings and to tell us whenever a .vocabrc changes.
+func (w *Workspace) Initialized() {
+	// nothing can be sent before the client is initialized
+	w.initialized.Store(true)
+	w.DiagnosticsChanged()
+
+	if w.client == nil || w.capabilities.Workspace == nil {
+		return
+	}
+
+	if watched := w.capabilities.Workspace.DidChangeWatchedFiles; watched != nil && watched.DynamicRegistration {
+		w.client.Request("client/registerCapability", lsproto.RegistrationParams{
+			Registrations: []lsproto.Registration{{
+				Id:     "vocabrc-watcher",
+				Method: "workspace/didChangeWatchedFiles",
+				RegisterOptions: map[string]any{
+					"watchers": []lsproto.FileSystemWatcher{{GlobPattern: "**/" + config.FileName}},
+				},
+			}},
+		}, nil)
+	}
+
+	w.PullSettings()
+}
+
+// Request the settings through workspace/configuration, if the client supports it.
+func (w *Workspace) PullSettings() {
+	if w.client == nil || w.capabilities.Workspace == nil || !w.capabilities.Workspace.Configuration {
+		return
+	}
+
+	w.client.Request("workspace/configuration", lsproto.ConfigurationParams{
+		Items: []lsproto.ConfigurationItem{{Section: config.Section}},
+	}, func(response lsproto.ResponseMessage) {
+		results, ok := response.Result.([]any)
+		if !ok || len(results) == 0 {
+			return
+		}
+		settings, _ := results[0].(map[string]any)
+		w.SetSettings(settings)
+	})
+}
+
+// Replace the client settings layer and re-harvest.
+func (w *Workspace) SetSettings(settings map[string]any) {
+	w.settings = config.FromSettings(settings)
+	w.reconfigure()
+	w.refresh()
+}
+
+// Reload the .vocabrc at uri, if it belongs to a workspace folder, and re-harvest.
+func (w *Workspace) ConfigFileChanged(uri string) {
+	for _, folder := range w.folders {
+		if folder.Uri+"/"+config.FileName != uri {
+			continue
+		}
+		w.loadConfigFile(folder)
+		w.reconfigure()
+		w.refresh()
+		return
+	}
+}
+
+func (w *Workspace) Config() config.Config {
+	return w.config
+}
+
+func (w *Workspace) Dictionaries() *dictionary.Library {
+	return w.dictionaries
+}
+
+// Plant all vocab files under folder.
+func (w *Workspace) AddFolder(folder lsproto.WorkspaceFolder) {
+	folder.Uri = strings.TrimSuffix(folder.Uri, "/")
+	w.folders = append(w.folders, folder)
+
+	w.loadConfigFile(folder)
+	if replanted := w.reconfigure(); !replanted {
+		w.plantFolder(folder)
+	}
+}
+
+// Uproot every file planted under folder.
+func (w *Workspace) RemoveFolder(folder lsproto.WorkspaceFolder) {
+	folder.Uri = strings.TrimSuffix(folder.Uri, "/")
+	w.folders = slices.DeleteFunc(w.folders, func(f lsproto.WorkspaceFolder) bool {
+		return f.Uri == folder.Uri
+	})
+	w.forest.RemoveFolder(folder.Uri)
+	w.forest.SetIndependent(folder.Uri, false)
+
+	if _, exists := w.configFiles[folder.Uri]; exists {
+		w.publishConfigDiagnostics(folder.Uri+"/"+config.FileName, []lsproto.Diagnostic{})
+		delete(w.configFiles, folder.Uri)
+		w.reconfigure()
+	}
+}
+
+func (w *Workspace) Folders() []lsproto.WorkspaceFolder {
+	return slices.Clone(w.folders)
+}
+
+func (w *Workspace) plantFolder(folder lsproto.WorkspaceFolder) {
+	root := lib.UriToPath(folder.Uri)
+	filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			w.logger.Logf("Can't walk %s: %v", path, err)
+			return nil
+		}
+		if !d.Type().IsRegular() || !w.config.IsPlantable(d.Name()) {
+			return nil
+		}
+
+		bytes, readErr := os.ReadFile(path)
+		if readErr != nil {
+			w.logger.Logf("Can't read content at %s", path)
+			return nil
+		}
+
+		w.forest.Plant(fileUriInFolder(folder.Uri, root, path), string(bytes), nil)
+
+		return nil
+	})
+}
+
+func (w *Workspace) loadConfigFile(folder lsproto.WorkspaceFolder) {
+	path := filepath.Join(lib.UriToPath(folder.Uri), config.FileName)
+	bytes, err := os.ReadFile(path)
+	if err != nil {
+		if existing, exists := w.configFiles[folder.Uri]; exists {
+			// deleted, clear whatever was reported on it
+			w.publishConfigDiagnostics(existing.Uri, []lsproto.Diagnostic{})
+			delete(w.configFiles, folder.Uri)
+		}
+		return
+	}
+	w.configFiles[folder.Uri] = config.ParseFile(folder.Uri+"/"+config.FileName, string(bytes))
+}
+
+// Merge defaults, every .vocabrc and the client settings, then apply the result to the forest.
+//
+// Returns true if every folder was planted again because the planted extensions changed.
+func (w *Workspace) reconfigure() bool {
+	sources := []*config.Source{}
+	for _, folder := range w.folders {
+		if source := w.configFiles[folder.Uri]; source != nil {
+			sources = append(sources, source)
+		}
+	}
+	sources = append(sources, w.settings)
+
+	resolved, diagnostics := config.Resolve(sources...)
+	for uri, diags := range diagnostics {
+		if uri == "" {
+			for _, diag := range diags {
+				w.logger.Log("Invalid client setting: ", diag.Message)
+			}
+			continue
+		}
+		w.publishConfigDiagnostics(uri, diags)
+	}
+
+	// independentSchedule only means something for the folder whose .vocabrc says so
+	for _, folder := range w.folders {
+		own, _ := config.Resolve(w.configFiles[folder.Uri])
+		independent := own.IndependentSchedule ||
+			slices.Contains(w.independentSchedules, folder.Uri) ||
+			slices.Contains(w.independentSchedules, folder.Name)
+		w.forest.SetIndependent(folder.Uri, independent)
+	}
+	resolved.IndependentSchedule = false
+
+	extensionsChanged := !slices.Equal(w.config.Extensions, resolved.Extensions)
+	lemmasChanged := !maps.Equal(w.config.Lemmas, resolved.Lemmas)
+	w.config = resolved
+	w.forest.Configure(resolved)
+	w.dictionaries.Configure(resolved.Dictionaries)
+	if lemmasChanged {
+		w.forest.WithLemmas(w.readLemmas(resolved.Lemmas))
+	}
+
+	if extensionsChanged {
+		for _, folder := range w.folders {
+			w.forest.RemoveFolder(folder.Uri)
+			w.plantFolder(folder)
+		}
+	}
+	return extensionsChanged
+}
+
+// Read the lemma list of every language of paths. Lists that can't be read are left out.
+func (w *Workspace) readLemmas(paths map[string]string) map[parser.Language]*dictionary.Lemmas {
+	lemmas := make(map[parser.Language]*dictionary.Lemmas)
+	for code, path := range paths {
+		list, err := dictionary.ReadLemmas(path)
+		if err != nil {
+			w.logger.Logf("Can't read the lemma list of %s: %v", code, err)
+			continue
+		}
+		lemmas[parser.LanguageOf(code)] = list
+	}
+	return lemmas
+}
+
+// Whether the client pulls diagnostics with textDocument/diagnostic. If not, they are pushed.
+func (w *Workspace) PullsDiagnostics() bool {
+	return w.capabilities.TextDocument != nil && w.capabilities.TextDocument.Diagnostic != nil
+}
+
+// Whether the client takes the reports of other documents along with the one it pulled.
+func (w *Workspace) RelatedDocumentSupport() bool {
+	return w.PullsDiagnostics() && w.capabilities.TextDocument.Diagnostic.RelatedDocumentSupport
+}
+
+// Push the diagnostics of every document that changed, once changes settle down.
+//
+// Clients pulling diagnostics ask for them on their own, this does nothing for them.
+func (w *Workspace) DiagnosticsChanged() {
+	if w.PullsDiagnostics() {
+		return
+	}
+	w.publisher.Schedule(w.forest.Config().Debounce())
+}
+
+// Words fall due with the day, re-harvest everything. Safe to call from any goroutine.
+func (w *Workspace) DayChanged() {
+	if !w.initialized.Load() {
+		return
+	}
+	w.refresh()
+}
+
+// Called every minute: words in their learning steps are due in minutes, so their diagnostics
+// are refreshed as they count down.
+func (w *Workspace) MinutePassed() {
+	if !w.initialized.Load() || !w.forest.Snapshot().Learning() {
+		return
+	}
+	w.refresh()
+}
+
+// Tell the client its diagnostics are stale.
+func (w *Workspace) refresh() {
+	if !w.PullsDiagnostics() {
+		w.DiagnosticsChanged()
+		return
+	}
+	if w.client == nil || w.capabilities.Workspace == nil {
+		return
+	}
+	if diagnostics := w.capabilities.Workspace.Diagnostics; diagnostics != nil && diagnostics.RefreshSupport {
+		w.client.Request("workspace/diagnostic/refresh", nil, nil)
+	}
+}
+
+// Send a partial result or progress value for token.
+func (w *Workspace) ReportProgress(token any, value any) {
+	if w.client == nil {
+		return
+	}
+	w.client.Notify("$/progress", lsproto.ProgressParams{Token: token, Value: value})
+}
+
+// The client never pulls diagnostics for a .vocabrc, so they are always pushed.
+func (w *Workspace) publishConfigDiagnostics(uri string, diags []lsproto.Diagnostic) {
+	if w.client == nil {
+		return
+	}
+	w.client.Notify("textDocument/publishDiagnostics", lsproto.PublishDiagnosticsParams{
+		Uri:         uri,
+		Diagnostics: diags,
+	})
+}
+
+// Glob of every file the forest plants, for file operation filters.
+func (w *Workspace) PlantableGlob() string {
+	if len(w.config.Extensions) == 1 {
+		return "**/*." + w.config.Extensions[0]
+	}
+	return fmt.Sprintf("**/*.{%s}", strings.Join(w.config.Extensions, ","))
+}
+
+// Build the uri of a file from the uri of its folder so that both are encoded the same way the
+// client encoded the folder.
+func fileUriInFolder(folderUri string, root string, path string) string {
+	relative, err := filepath.Rel(root, path)
+	if err != nil {
+		return PathToLspUri(path)
+	}
+	segments := strings.Split(filepath.ToSlash(relative), "/")
+	for i := range segments {
+		segments[i] = url.PathEscape(segments[i])
+	}
+	return folderUri + "/" + strings.Join(segments, "/")
+}
+
+func PathToLspUri(path string) string {
+	if runtime.GOOS != "windows" {
+		return fmt.Sprintf("%s%s", "file://", path)
+	}
+
+	return TransformWindowsPathToLspUri(path)
+}
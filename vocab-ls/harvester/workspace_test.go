@@ -0,0 +1,188 @@
+package harvester
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"testing"
+	"vocab/lib"
+	lsproto "vocab/lsp"
+	test "vocab/vocab_testing"
+	"vocab/vocabulary/forest"
+)
+
+func writeVocabFile(t *testing.T, path string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte("01/01/2025\n> (it) mostrare"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWorkspace_ShouldPlantEveryFolderAndRemoveThemAgain(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		return
+	}
+
+	italian := t.TempDir()
+	german := t.TempDir()
+	writeVocabFile(t, filepath.Join(italian, "it.vocab"))
+	writeVocabFile(t, filepath.Join(italian, "nested dir", "more.vocab"))
+	writeVocabFile(t, filepath.Join(italian, "notes.txt"))
+	writeVocabFile(t, filepath.Join(german, "de.vocab"))
+
+	f := forest.NewForest(t.Context(), func(any) {})
+	workspace := NewWorkspace(f, nil, lib.NewLogger(os.Stderr))
+	rootPath := italian
+	workspace.Initialize(&lsproto.InitializeParams{
+		// should be ignored in favour of workspaceFolders
+		RootPath: &rootPath,
+		WorkspaceFolders: []lsproto.WorkspaceFolder{
+			{Uri: "file://" + italian, Name: "italian"},
+			{Uri: "file://" + german, Name: "german"},
+		},
+	})
+
+	harvested := f.Harvest()
+	test.Expect(t, 3, len(harvested))
+	test.Expect(t, true, harvested["file://"+italian+"/it.vocab"] != nil)
+	test.Expect(t, true, harvested["file://"+italian+"/nested%20dir/more.vocab"] != nil)
+	test.Expect(t, true, harvested["file://"+german+"/de.vocab"] != nil)
+
+	workspace.RemoveFolder(lsproto.WorkspaceFolder{Uri: "file://" + italian, Name: "italian"})
+	harvested = f.Harvest()
+	test.Expect(t, 1, len(harvested))
+	test.Expect(t, 1, len(workspace.Folders()))
+}
+
+func TestWorkspace_ShouldFallBackToRootPathWhenEverythingElseIsNull(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		return
+	}
+
+	root := t.TempDir()
+	writeVocabFile(t, filepath.Join(root, "it.vocab"))
+
+	f := forest.NewForest(t.Context(), func(any) {})
+	workspace := NewWorkspace(f, nil, lib.NewLogger(os.Stderr))
+	workspace.Initialize(&lsproto.InitializeParams{RootPath: &root})
+
+	test.Expect(t, 1, len(f.Harvest()))
+	test.Expect(t, 1, len(workspace.Folders()))
+}
+
+func TestInitializeWorker_ShouldNotPanicOnNullRootPath(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		return
+	}
+
+	root := t.TempDir()
+	writeVocabFile(t, filepath.Join(root, "it.vocab"))
+
+	f := forest.NewForest(t.Context(), func(any) {})
+	logger := lib.NewLogger(os.Stderr)
+	worker := NewRequestWorker(f, NewWorkspace(f, nil, logger), logger)
+	_, err := worker.InitializeWorker(lsproto.RequestMessage{
+		ID:     0,
+		Method: lsproto.Initialize,
+		Params: map[string]any{
+			"rootPath":         nil,
+			"rootUri":          "file://" + root,
+			"workspaceFolders": nil,
+		},
+	})
+
+	test.Expect(t, true, err == nil)
+	test.Expect(t, 1, len(f.Harvest()))
+}
+
+type fakeClient struct {
+	// diagnostics are published from a timer
+	mutex         sync.Mutex
+	requests      []string
+	responders    map[string]func(lsproto.ResponseMessage)
+	notifications []lsproto.PublishDiagnosticsParams
+	progress      []lsproto.ProgressParams
+}
+
+func newFakeClient() *fakeClient {
+	return &fakeClient{responders: make(map[string]func(lsproto.ResponseMessage))}
+}
+
+func (c *fakeClient) Request(method string, params any, onResponse func(lsproto.ResponseMessage)) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.requests = append(c.requests, method)
+	c.responders[method] = onResponse
+}
+
+func (c *fakeClient) Notify(method string, params any) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	switch params := params.(type) {
+	case lsproto.PublishDiagnosticsParams:
+		c.notifications = append(c.notifications, params)
+	case lsproto.ProgressParams:
+		c.progress = append(c.progress, params)
+	}
+}
+
+func TestWorkspace_ShouldReadVocabrcOfEachFolder(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		return
+	}
+
+	journal := t.TempDir()
+	writeVocabFile(t, filepath.Join(journal, "it.vocab"))
+	writeVocabFile(t, filepath.Join(journal, "notes.md"))
+	os.WriteFile(filepath.Join(journal, ".vocabrc"), []byte("extensions = [\"vocab\", \"md\"]\nindependentSchedule = true\ncolour = 1"), 0o644)
+
+	f := forest.NewForest(t.Context(), func(any) {})
+	client := newFakeClient()
+	workspace := NewWorkspace(f, client, lib.NewLogger(os.Stderr))
+	workspace.Initialize(&lsproto.InitializeParams{
+		WorkspaceFolders: []lsproto.WorkspaceFolder{{Uri: "file://" + journal, Name: "journal"}},
+	})
+
+	test.Expect(t, 2, len(f.Harvest()))
+	test.Expect(t, "**/*.{vocab,md}", workspace.PlantableGlob())
+	// independentSchedule is folder specific and never leaks into the merged config
+	test.Expect(t, false, workspace.Config().IndependentSchedule)
+
+	// the unknown key
+	last := client.notifications[len(client.notifications)-1]
+	test.Expect(t, "file://"+journal+"/.vocabrc", last.Uri)
+	test.Expect(t, 1, len(last.Diagnostics))
+	test.Expect(t, 2, last.Diagnostics[0].Range.Start.Line)
+}
+
+func TestWorkspace_ShouldPullClientSettingsAndRefresh(t *testing.T) {
+	f := forest.NewForest(t.Context(), func(any) {})
+	client := newFakeClient()
+	workspace := NewWorkspace(f, client, lib.NewLogger(os.Stderr))
+	workspace.Initialize(&lsproto.InitializeParams{
+		Capabilities: lsproto.ClientCapabilities{
+			Workspace: &lsproto.WorkspaceClientCapabilities{
+				Configuration: true,
+				Diagnostics:   &lsproto.DiagnosticWorkspaceClientCapabilities{RefreshSupport: true},
+			},
+			TextDocument: &lsproto.TextDocumentClientCapabilities{
+				Diagnostic: &lsproto.DiagnosticClientCapabilities{},
+			},
+		},
+	})
+
+	workspace.Initialized()
+	test.Expect(t, 1, len(client.requests))
+	test.Expect(t, "workspace/configuration", client.requests[0])
+
+	client.responders["workspace/configuration"](lsproto.ResponseMessage{
+		Result: []any{map[string]any{"diagnostics": map[string]any{"hintWithinDays": 10.0}}},
+	})
+	test.Expect(t, 10.0, workspace.Config().Diagnostics.HintWithinDays)
+	test.Expect(t, 10.0, f.Config().Diagnostics.HintWithinDays)
+	test.Expect(t, "workspace/diagnostic/refresh", client.requests[len(client.requests)-1])
+}
@@ -0,0 +1,338 @@
+package importer
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"vocab/vocabulary/parser"
+)
+
+// Date layout of Anki review log ids, milliseconds since the epoch.
+const AnkiReviewLogLayout = "unixms"
+
+// Where each field of an entry is, and how to read it.
+type Mapping struct {
+	// Columns by header name, or by number starting at 1. Word and Date are required.
+	Word  string
+	Lang  string
+	Date  string
+	Grade string
+	// Ease of an Anki review log, 1 to 4, read instead of Grade
+	Ease string
+	// Whether the word is graded in both directions, true or false, and the grade of producing it
+	TwoWay          string
+	ProductionGrade string
+	// Language code of the entries without a Lang column, as in it
+	DefaultLang string
+	// Go layout of the Date column, or AnkiReviewLogLayout. yyyy-mm-dd if empty.
+	DateLayout string
+	// Field separator, tab if the first line has one and comma otherwise if 0
+	Separator rune
+	// Skip the first row. Always the case when a column is given by name.
+	Header bool
+}
+
+// One review of a word.
+type Entry struct {
+	Word string
+	Lang parser.Language
+	Date time.Time
+	// Only meaningful if Graded
+	Grade  int
+	Graded bool
+	// Graded in both directions, either of which can be left out
+	TwoWay bool
+	// Only meaningful if ProductionGraded
+	ProductionGrade  int
+	ProductionGraded bool
+}
+
+// Read every entry of a CSV or TSV file. Lines starting with # are skipped, like the headers of
+// Anki exports.
+func Read(in io.Reader, mapping Mapping) ([]Entry, error) {
+	content, err := io.ReadAll(in)
+	if err != nil {
+		return nil, err
+	}
+
+	reader := csv.NewReader(bytes.NewReader(content))
+	reader.Comma = mapping.Separator
+	if reader.Comma == 0 {
+		reader.Comma = guessSeparator(string(content))
+	}
+	reader.Comment = '#'
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return []Entry{}, nil
+	}
+
+	header := []string{}
+	byName := false
+	for _, column := range []string{mapping.Word, mapping.Lang, mapping.Date, mapping.Grade, mapping.Ease, mapping.TwoWay, mapping.ProductionGrade} {
+		if _, err := strconv.Atoi(column); column != "" && err != nil {
+			byName = true
+		}
+	}
+	if byName || mapping.Header {
+		header = records[0]
+		records = records[1:]
+	}
+
+	word, err := columnIndex(header, "word", mapping.Word, true)
+	if err != nil {
+		return nil, err
+	}
+	date, err := columnIndex(header, "date", mapping.Date, true)
+	if err != nil {
+		return nil, err
+	}
+	lang, err := columnIndex(header, "lang", mapping.Lang, mapping.DefaultLang == "")
+	if err != nil {
+		return nil, err
+	}
+	grade, err := columnIndex(header, "grade", mapping.Grade, false)
+	if err != nil {
+		return nil, err
+	}
+	ease, err := columnIndex(header, "ease", mapping.Ease, false)
+	if err != nil {
+		return nil, err
+	}
+	twoWay, err := columnIndex(header, "two way", mapping.TwoWay, false)
+	if err != nil {
+		return nil, err
+	}
+	production, err := columnIndex(header, "production grade", mapping.ProductionGrade, false)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := []Entry{}
+	for i, record := range records {
+		row := i + 1
+		if byName || mapping.Header {
+			row++
+		}
+		field := func(index int) string {
+			if index < 0 || index >= len(record) {
+				return ""
+			}
+			return strings.TrimSpace(record[index])
+		}
+
+		entry := Entry{Word: field(word)}
+		if entry.Word == "" {
+			continue
+		}
+
+		code := mapping.DefaultLang
+		if lang >= 0 {
+			code = cmp.Or(field(lang), code)
+		}
+		entry.Lang = parser.LanguageOf(strings.ToLower(code))
+		if entry.Lang == parser.Unrecognized {
+			return nil, fmt.Errorf("row %d: unrecognized language %q, expect it, de or fr", row, code)
+		}
+
+		entry.Date, err = parseDate(field(date), mapping.DateLayout)
+		if err != nil {
+			return nil, fmt.Errorf("row %d: %w", row, err)
+		}
+
+		switch {
+		case ease >= 0 && field(ease) != "":
+			entry.Grade, err = easeToGrade(field(ease))
+			entry.Graded = true
+		case grade >= 0 && field(grade) != "":
+			entry.Grade, err = parseGrade(field(grade))
+			entry.Graded = true
+		}
+		if err != nil {
+			return nil, fmt.Errorf("row %d: %w", row, err)
+		}
+
+		if twoWay >= 0 && field(twoWay) != "" {
+			if entry.TwoWay, err = strconv.ParseBool(field(twoWay)); err != nil {
+				return nil, fmt.Errorf("row %d: expect two way to be true or false, got %q", row, field(twoWay))
+			}
+		}
+		if entry.TwoWay && production >= 0 && field(production) != "" {
+			if entry.ProductionGrade, err = parseGrade(field(production)); err != nil {
+				return nil, fmt.Errorf("row %d: %w", row, err)
+			}
+			entry.ProductionGraded = true
+		}
+
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+func guessSeparator(content string) rune {
+	for line := range strings.Lines(content) {
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+		if strings.Contains(line, "\t") {
+			return '\t'
+		}
+		break
+	}
+	return ','
+}
+
+// Index of the column given by name or number, -1 if it isn't given and isn't required.
+func columnIndex(header []string, field string, column string, required bool) (int, error) {
+	if column == "" {
+		if required {
+			return -1, fmt.Errorf("expect a column for the %s", field)
+		}
+		return -1, nil
+	}
+	if number, err := strconv.Atoi(column); err == nil {
+		if number < 1 {
+			return -1, fmt.Errorf("expect column numbers to start at 1, got %d for the %s", number, field)
+		}
+		return number - 1, nil
+	}
+	for i, name := range header {
+		if strings.EqualFold(strings.TrimSpace(name), column) {
+			return i, nil
+		}
+	}
+	return -1, fmt.Errorf("no column named %q for the %s", column, field)
+}
+
+func parseDate(text string, layout string) (time.Time, error) {
+	switch layout {
+	case AnkiReviewLogLayout:
+		milliseconds, err := strconv.ParseInt(text, 10, 64)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("expect a review log id in milliseconds, got %q", text)
+		}
+		// the day the review was done on, where it was done
+		reviewed := time.UnixMilli(milliseconds).In(time.Local)
+		return time.Date(reviewed.Year(), reviewed.Month(), reviewed.Day(), 0, 0, 0, 0, time.UTC), nil
+	case "":
+		layout = time.DateOnly
+	}
+	parsed, err := time.Parse(layout, text)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("expect a date like %s, got %q", layout, text)
+	}
+	return parsed, nil
+}
+
+func parseGrade(text string) (int, error) {
+	grade, err := strconv.Atoi(text)
+	if err == nil && (grade < 0 || grade > 5) {
+		err = fmt.Errorf("expect grade to be from 0 to 5, got %d", grade)
+	}
+	return grade, err
+}
+
+// Anki grades with Again, Hard, Good and Easy. Again is a failure, the others are passes with more
+// or less hesitation.
+func easeToGrade(text string) (int, error) {
+	ease, err := strconv.Atoi(text)
+	if err != nil || ease < 1 || ease > 4 {
+		return 0, fmt.Errorf("expect an ease from 1 to 4, got %q", text)
+	}
+	return []int{1, 3, 4, 5}[ease-1], nil
+}
+
+// Write entries as dated sections, the first time a word appears under > and every other time
+// under >>, so the forest schedules them as they were scheduled before.
+//
+// Only the first review of a word on a day is kept: later ones are relearning the word that was
+// just failed, which a journal entry can't tell apart.
+func Sections(entries []Entry, dateLayout string) string {
+	sorted := slices.Clone(entries)
+	slices.SortStableFunc(sorted, func(a, b Entry) int {
+		return a.Date.Compare(b.Date)
+	})
+
+	var out strings.Builder
+	seen := map[string]struct{}{}
+	for i := 0; i < len(sorted); {
+		date := sorted[i].Date
+		end := i
+		for end < len(sorted) && sorted[end].Date.Equal(date) {
+			end++
+		}
+		day := sorted[i:end]
+		i = end
+
+		fmt.Fprintln(&out, date.Format(dateLayout))
+		langs := []parser.Language{}
+		for _, entry := range day {
+			if !slices.Contains(langs, entry.Lang) {
+				langs = append(langs, entry.Lang)
+			}
+		}
+		slices.SortFunc(langs, func(a, b parser.Language) int {
+			return strings.Compare(a.Code(), b.Code())
+		})
+
+		for _, lang := range langs {
+			written := map[string]struct{}{}
+			newWords := []string{}
+			reviewedWords := []string{}
+			for _, entry := range day {
+				key := strings.ToLower(entry.Word)
+				if _, exists := written[key]; entry.Lang != lang || exists {
+					continue
+				}
+				written[key] = struct{}{}
+
+				word := formatWord(entry)
+				if _, exists := seen[lang.Code()+key]; exists {
+					reviewedWords = append(reviewedWords, word)
+				} else {
+					newWords = append(newWords, word)
+				}
+				seen[lang.Code()+key] = struct{}{}
+			}
+			if len(newWords) > 0 {
+				fmt.Fprintf(&out, "> (%s) %s\n", lang.Code(), strings.Join(newWords, ", "))
+			}
+			if len(reviewedWords) > 0 {
+				fmt.Fprintf(&out, ">> (%s) %s\n", lang.Code(), strings.Join(reviewedWords, ", "))
+			}
+		}
+	}
+	return out.String()
+}
+
+// Words with characters of the syntax in them are kept as they are between backticks.
+func formatWord(entry Entry) string {
+	word := entry.Word
+	if strings.ContainsAny(word, ",()|=`") || strings.Contains(word, "::") {
+		word = "`" + strings.ReplaceAll(word, "`", "") + "`"
+	}
+	side := func(grade int, graded bool) string {
+		if !graded {
+			return ""
+		}
+		return strconv.Itoa(grade)
+	}
+	switch {
+	// both sides left out can't be written, the word is then as good as ungraded
+	case entry.TwoWay && (entry.Graded || entry.ProductionGraded):
+		word += fmt.Sprintf("(%s/%s)", side(entry.Grade, entry.Graded), side(entry.ProductionGrade, entry.ProductionGraded))
+	case entry.Graded:
+		word += fmt.Sprintf("(%d)", entry.Grade)
+	}
+	return word
+}
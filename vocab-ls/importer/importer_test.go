@@ -0,0 +1,107 @@
+package importer
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+	"vocab/export"
+	test "vocab/vocab_testing"
+	"vocab/vocabulary/forest"
+	"vocab/vocabulary/parser"
+)
+
+func TestRead_ShouldMapColumnsByName(t *testing.T) {
+	entries, err := Read(strings.NewReader(test.TrimLines(`
+		Date,Word,Language,Score
+		2025-06-01,la casa,it,3
+		2025-06-01,"der Hund, der",DE,
+	`)), Mapping{Word: "word", Date: "date", Lang: "language", Grade: "score"})
+
+	test.Expect(t, nil, err)
+	test.Expect(t, 2, len(entries))
+	test.Expect(t, "la casa", entries[0].Word)
+	test.Expect(t, parser.Italiano, entries[0].Lang)
+	test.Expect(t, 3, entries[0].Grade)
+	test.Expect(t, true, entries[0].Graded)
+	test.Expect(t, parser.Deutsch, entries[1].Lang)
+	test.Expect(t, false, entries[1].Graded)
+}
+
+func TestRead_ShouldReadAnkiReviewLogs(t *testing.T) {
+	reviewed := time.Date(2025, time.June, 1, 9, 0, 0, 0, time.Local)
+	entries, err := Read(strings.NewReader(test.TrimLines(`
+		#separator:tab
+		casa	`+strconvMillis(reviewed)+`	1
+		casa	`+strconvMillis(reviewed.AddDate(0, 0, 1))+`	3
+	`)), Mapping{Word: "1", Date: "2", Ease: "3", DefaultLang: "it", DateLayout: AnkiReviewLogLayout})
+
+	test.Expect(t, nil, err)
+	test.Expect(t, 2, len(entries))
+	test.Expect(t, "2025-06-01", entries[0].Date.Format(time.DateOnly))
+	// again is a failure, good a pass after some hesitation
+	test.Expect(t, 1, entries[0].Grade)
+	test.Expect(t, 4, entries[1].Grade)
+}
+
+func TestRead_ShouldTellWhichRowIsWrong(t *testing.T) {
+	_, err := Read(strings.NewReader("casa,2025-06-01\ncane,yesterday"), Mapping{Word: "1", Date: "2", DefaultLang: "it"})
+	test.Expect(t, true, err != nil && strings.HasPrefix(err.Error(), "row 2:"))
+
+	_, err = Read(strings.NewReader("word,date\ncasa,2025-06-01"), Mapping{Word: "word", Date: "date"})
+	test.Expect(t, true, err != nil && strings.Contains(err.Error(), "lang"))
+}
+
+func TestSections_ShouldReconstructTheSchedule(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2025, time.June, d, 0, 0, 0, 0, time.UTC) }
+	entries := []Entry{
+		{Word: "casa", Lang: parser.Italiano, Date: day(7), Grade: 4, Graded: true},
+		{Word: "casa", Lang: parser.Italiano, Date: day(1), Grade: 5, Graded: true},
+		{Word: "der Hund", Lang: parser.Deutsch, Date: day(1), Grade: 4, Graded: true},
+		{Word: "casa", Lang: parser.Italiano, Date: day(2), Grade: 5, Graded: true},
+		// relearning the same day doesn't count
+		{Word: "casa", Lang: parser.Italiano, Date: day(2), Grade: 1, Graded: true},
+		{Word: "a, b", Lang: parser.Italiano, Date: day(2)},
+	}
+
+	sections := Sections(entries, "02/01/2006")
+	test.Expect(t, test.TrimLines(`
+		01/06/2025
+		> (de) der Hund(4)
+		> (it) casa(5)
+		02/06/2025
+		> (it) `+"`a, b`"+`
+		>> (it) casa(5)
+		07/06/2025
+		>> (it) casa(4)
+	`)+"\n", sections)
+
+	f := forest.NewForest(t.Context(), func(any) {})
+	f.Plant("file:///imported.vocab", sections, nil)
+	// nothing the parser complains about
+	for _, harvested := range f.Harvest()["file:///imported.vocab"] {
+		test.Expect(t, true, harvested.Word != "")
+	}
+	history := f.History()
+	test.Expect(t, 5, len(history))
+	test.Expect(t, "a, b", history[2].Word)
+}
+
+func TestSections_ShouldReadExportedCsvBack(t *testing.T) {
+	f := forest.NewForest(t.Context(), func(any) {})
+	f.Plant("file:///de.vocab", "01/06/2025\n> (de) Haus(5/3), Hund(/3), Katze(4/), Maus(2)", nil)
+	var exported bytes.Buffer
+	test.Expect(t, nil, export.Write(&exported, export.FormatCsv, export.NewRows(f.History(), nil)))
+
+	entries, err := Read(&exported, Mapping{Word: "word", Date: "date", Lang: "lang", Grade: "grade", TwoWay: "twoWay", ProductionGrade: "productionGrade"})
+	test.Expect(t, nil, err)
+	test.Expect(t, test.TrimLines(`
+		01/06/2025
+		> (de) Haus(5/3), Hund(/3), Katze(4/), Maus(2)
+	`)+"\n", Sections(entries, "02/01/2006"))
+}
+
+func strconvMillis(t time.Time) string {
+	return strconv.FormatInt(t.UnixMilli(), 10)
+}
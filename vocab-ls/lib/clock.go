@@ -0,0 +1,38 @@
+package lib
+
+import "time"
+
+// Clock tells the time to everything that depends on what day it is, so that it can be pinned
+// in tests or moved to another day.
+type Clock interface {
+	Now() time.Time
+}
+
+type SystemClock struct{}
+
+func (SystemClock) Now() time.Time {
+	return time.Now()
+}
+
+// A clock stopped at Time.
+type FixedClock struct {
+	Time time.Time
+}
+
+func (c FixedClock) Now() time.Time {
+	return c.Time
+}
+
+// The calendar day of t, as midnight UTC like every date parsed from a vocab file.
+func Day(t time.Time) time.Time {
+	year, month, day := t.Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+// The wall clock reading of t, in UTC like the times of sections, so that a section written at
+// 18:30 compares with 18:30 wherever the file is read.
+func Moment(t time.Time) time.Time {
+	year, month, day := t.Date()
+	hour, minute, second := t.Clock()
+	return time.Date(year, month, day, hour, minute, second, t.Nanosecond(), time.UTC)
+}
@@ -1,55 +1,71 @@
-package lib
-
-import (
-	"context"
-	"runtime"
-	"sync"
-)
-
-// CPU-bound worker pool for cpu-intensive tasks.
-type GoWorkerPool struct {
-	ctx           context.Context
-	wg            sync.WaitGroup
-	channel       chan struct{}
-	resourceMutex sync.Map
-}
-
-func NewGoWorkerPool(ctx context.Context) *GoWorkerPool {
-	return &GoWorkerPool{
-		ctx:     ctx,
-		channel: make(chan struct{}, runtime.NumCPU()),
-		wg:      sync.WaitGroup{},
-	}
-}
-
-// Spawn a goroutine to perform work.
-//
-// Blocks until there are remaining workers to schedule work onto.
-func (pool *GoWorkerPool) Run(resource string, work func()) {
-	pool.channel <- struct{}{}
-	pool.wg.Add(1)
-	go func() {
-		defer func() {
-			pool.wg.Done()
-			<-pool.channel
-		}()
-
-		runtime.LockOSThread()
-		defer runtime.UnlockOSThread()
-
-		pool.workWithMutex(resource, work)
-	}()
-}
-
-func (pool *GoWorkerPool) WaitAll() {
-	pool.wg.Wait()
-}
-
-func (pool *GoWorkerPool) workWithMutex(res string, work func()) {
-	got, _ := pool.resourceMutex.LoadOrStore(res, &sync.Mutex{})
-	mutex := got.(*sync.Mutex)
-
-	mutex.Lock()
-	defer mutex.Unlock()
-	work()
-}
+package lib
+
+import (
+	"context"
+	"runtime"
+	"sync"
+)
+
+// CPU-bound worker pool for cpu-intensive tasks.
+type GoWorkerPool struct {
+	ctx     context.Context
+	channel chan struct{}
+	// Work can be added while someone waits, which a WaitGroup does not allow
+	pendingMutex  sync.Mutex
+	pendingCond   *sync.Cond
+	pending       int
+	resourceMutex sync.Map
+}
+
+func NewGoWorkerPool(ctx context.Context) *GoWorkerPool {
+	pool := &GoWorkerPool{
+		ctx:     ctx,
+		channel: make(chan struct{}, runtime.NumCPU()),
+	}
+	pool.pendingCond = sync.NewCond(&pool.pendingMutex)
+	return pool
+}
+
+// Spawn a goroutine to perform work.
+//
+// Blocks until there are remaining workers to schedule work onto.
+func (pool *GoWorkerPool) Run(resource string, work func()) {
+	pool.channel <- struct{}{}
+	pool.pendingMutex.Lock()
+	pool.pending++
+	pool.pendingMutex.Unlock()
+	go func() {
+		defer func() {
+			<-pool.channel
+			pool.pendingMutex.Lock()
+			pool.pending--
+			if pool.pending == 0 {
+				pool.pendingCond.Broadcast()
+			}
+			pool.pendingMutex.Unlock()
+		}()
+
+		runtime.LockOSThread()
+		defer runtime.UnlockOSThread()
+
+		pool.workWithMutex(resource, work)
+	}()
+}
+
+// Block until no work is running, including work started while waiting.
+func (pool *GoWorkerPool) WaitAll() {
+	pool.pendingMutex.Lock()
+	defer pool.pendingMutex.Unlock()
+	for pool.pending > 0 {
+		pool.pendingCond.Wait()
+	}
+}
+
+func (pool *GoWorkerPool) workWithMutex(res string, work func()) {
+	got, _ := pool.resourceMutex.LoadOrStore(res, &sync.Mutex{})
+	mutex := got.(*sync.Mutex)
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	work()
+}
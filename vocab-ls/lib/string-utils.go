@@ -118,6 +118,7 @@ const (
 	Dash            rune = 0x2d // -
 	Dot             rune = 0x2e // .
 	DoubleQuote     rune = 0x22 // "
+	Equals          rune = 0x3d // =
 	ExclamationMark rune = 0x21 // !
 	GreaterThan     rune = 0x3e // >
 	LessThan        rune = 0x3c // <
@@ -0,0 +1,23 @@
+package lib
+
+import (
+	"net/url"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// Turn a file uri back into a path on this machine.
+func UriToPath(uri string) string {
+	parsed, err := url.Parse(uri)
+	if err != nil || parsed.Scheme != "file" {
+		return uri
+	}
+
+	path := parsed.Path
+	if runtime.GOOS == "windows" {
+		// file:///c%3A/Users -> /c:/Users -> c:\Users
+		path = filepath.FromSlash(strings.TrimPrefix(path, "/"))
+	}
+	return path
+}
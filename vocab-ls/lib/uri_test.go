@@ -0,0 +1,15 @@
+package lib
+
+import (
+	"runtime"
+	"testing"
+	test "vocab/vocab_testing"
+)
+
+func TestUriToPath(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		return
+	}
+
+	test.Expect(t, "/Users/world/my vocab", UriToPath("file:///Users/world/my%20vocab"))
+}
@@ -75,17 +75,124 @@ type DidOpenDocumentParams struct {
 	TextDocument *TextDocumentItem
 }
 
+type WorkspaceFolder struct {
+	Uri  string `json:"uri"`
+	Name string `json:"name"`
+}
+
+type InitializationOptions struct {
+	// Workspace folders (by uri or name) whose schedules are harvested separately from the rest.
+	IndependentSchedules []string `json:"independentSchedules,omitempty"`
+}
+
+// https://microsoft.github.io/language-server-protocol/specifications/lsp/3.17/specification/#initializeParams
+type InitializeParams struct {
+	// Deprecated in favour of rootUri and workspaceFolders, may be null.
+	RootPath *string `json:"rootPath,omitempty"`
+	// Deprecated in favour of workspaceFolders, may be null.
+	RootUri               *string                `json:"rootUri,omitempty"`
+	WorkspaceFolders      []WorkspaceFolder      `json:"workspaceFolders,omitempty"`
+	InitializationOptions *InitializationOptions `json:"initializationOptions,omitempty"`
+	Capabilities          ClientCapabilities     `json:"capabilities"`
+}
+
+// Only the client capabilities the server acts on.
+//
+// https://microsoft.github.io/language-server-protocol/specifications/lsp/3.17/specification/#clientCapabilities
+type ClientCapabilities struct {
+	Workspace    *WorkspaceClientCapabilities    `json:"workspace,omitempty"`
+	TextDocument *TextDocumentClientCapabilities `json:"textDocument,omitempty"`
+}
+
+type TextDocumentClientCapabilities struct {
+	// Present if the client can pull diagnostics with textDocument/diagnostic
+	Diagnostic *DiagnosticClientCapabilities `json:"diagnostic,omitempty"`
+}
+
+type DiagnosticClientCapabilities struct {
+	RelatedDocumentSupport bool `json:"relatedDocumentSupport,omitempty"`
+}
+
+type WorkspaceClientCapabilities struct {
+	// Supports workspace/configuration requests
+	Configuration         bool                                   `json:"configuration,omitempty"`
+	DidChangeWatchedFiles *DidChangeWatchedFilesCapabilities     `json:"didChangeWatchedFiles,omitempty"`
+	Diagnostics           *DiagnosticWorkspaceClientCapabilities `json:"diagnostics,omitempty"`
+}
+
+type DidChangeWatchedFilesCapabilities struct {
+	DynamicRegistration bool `json:"dynamicRegistration,omitempty"`
+}
+
+type DiagnosticWorkspaceClientCapabilities struct {
+	// Supports workspace/diagnostic/refresh requests
+	RefreshSupport bool `json:"refreshSupport,omitempty"`
+}
+
+type ConfigurationItem struct {
+	ScopeUri string `json:"scopeUri,omitempty"`
+	Section  string `json:"section,omitempty"`
+}
+
+type ConfigurationParams struct {
+	Items []ConfigurationItem `json:"items"`
+}
+
+type DidChangeConfigurationParams struct {
+	Settings any `json:"settings"`
+}
+
+type FileChangeType int
+
+const (
+	FileChangeTypeCreated FileChangeType = iota + 1
+	FileChangeTypeChanged
+	FileChangeTypeDeleted
+)
+
+type FileEvent struct {
+	Uri  string         `json:"uri"`
+	Type FileChangeType `json:"type"`
+}
+
+type DidChangeWatchedFilesParams struct {
+	Changes []FileEvent `json:"changes"`
+}
+
+type FileSystemWatcher struct {
+	GlobPattern string `json:"globPattern"`
+}
+
+type Registration struct {
+	Id              string `json:"id"`
+	Method          string `json:"method"`
+	RegisterOptions any    `json:"registerOptions,omitempty"`
+}
+
+type RegistrationParams struct {
+	Registrations []Registration `json:"registrations"`
+}
+
+type WorkspaceFoldersChangeEvent struct {
+	Added   []WorkspaceFolder `json:"added"`
+	Removed []WorkspaceFolder `json:"removed"`
+}
+
+type DidChangeWorkspaceFoldersParams struct {
+	Event WorkspaceFoldersChangeEvent `json:"event"`
+}
+
 func NewTextDocumentHoverResponse(requestId int, content string, r *Range) *map[string]any {
-	return NewGenericResponse(
-		requestId,
-		map[string]any{
-			"contents": map[string]any{
-				"kind":  "plaintext",
-				"value": content,
-			},
-			"range": r,
+	result := map[string]any{
+		"contents": map[string]any{
+			"kind":  "plaintext",
+			"value": content,
 		},
-	)
+	}
+	if r != nil {
+		result["range"] = r
+	}
+	return NewGenericResponse(requestId, result)
 }
 
 func NewGenericResponse(messageId int, result map[string]any) *map[string]any {
@@ -96,28 +203,29 @@ func NewGenericResponse(messageId int, result map[string]any) *map[string]any {
 	}
 }
 
-type HoverParams struct {
-	TextDocument string   `json:"textDocument"`
-	Position     Position `json:"position"`
+// For requests that found nothing, such as a hover away from any word.
+func NewNullResponse(messageId int) *map[string]any {
+	return &map[string]any{
+		"jsonrpc": JsonRPCVersion,
+		"id":      messageId,
+		"result":  nil,
+	}
 }
 
-func NewFullDocumentDiagnosticResponse(id int, documentsDiagnostics []Diagnostic, relatedDocumentsDiagnostics map[string][]Diagnostic) *documentDiagnosticResponse {
-	reports := map[string]FullDocumentDiagnosticReport{}
-
-	for key := range relatedDocumentsDiagnostics {
-		reports[key] = FullDocumentDiagnosticReport{
-			Kind:  DocumentDiagnosticReportKindFull,
-			Items: relatedDocumentsDiagnostics[key],
-		}
-	}
+type HoverParams struct {
+	TextDocument TextDocumentIdentifier `json:"textDocument"`
+	Position     Position               `json:"position"`
+}
 
+func NewDocumentDiagnosticResponse(id int, report SingleDocumentDiagnosticReport, related map[string]SingleDocumentDiagnosticReport) *documentDiagnosticResponse {
 	return &documentDiagnosticResponse{
 		Jsonrpc: JsonRPCVersion,
 		ID:      id,
 		Result: DocumentDiagnosticReport{
-			Kind:             DocumentDiagnosticReportKindFull,
-			Items:            documentsDiagnostics,
-			RelatedDocuments: reports,
+			Kind:             report.Kind,
+			ResultId:         report.ResultId,
+			Items:            report.Items,
+			RelatedDocuments: related,
 		},
 	}
 }
@@ -130,14 +238,93 @@ type documentDiagnosticResponse struct {
 }
 
 type DocumentDiagnosticReport struct {
-	Kind             DocumentDiagnosticReportKind            `json:"kind"`
-	Items            []Diagnostic                            `json:"items"`
-	RelatedDocuments map[string]FullDocumentDiagnosticReport `json:"relatedDocuments,omitempty"`
+	Kind     DocumentDiagnosticReportKind `json:"kind"`
+	ResultId string                       `json:"resultId,omitempty"`
+	// Absent from unchanged reports
+	Items            []Diagnostic                              `json:"items,omitzero"`
+	RelatedDocuments map[string]SingleDocumentDiagnosticReport `json:"relatedDocuments,omitempty"`
+}
+
+// Either a full report with every item, or an unchanged report telling the client to keep the
+// items it got with ResultId.
+type SingleDocumentDiagnosticReport struct {
+	Kind     DocumentDiagnosticReportKind `json:"kind"`
+	ResultId string                       `json:"resultId,omitempty"`
+	// Absent from unchanged reports
+	Items []Diagnostic `json:"items,omitzero"`
+}
+
+func NewFullReport(resultId string, items []Diagnostic) SingleDocumentDiagnosticReport {
+	if items == nil {
+		items = []Diagnostic{}
+	}
+	return SingleDocumentDiagnosticReport{Kind: DocumentDiagnosticReportKindFull, ResultId: resultId, Items: items}
+}
+
+func NewUnchangedReport(resultId string) SingleDocumentDiagnosticReport {
+	return SingleDocumentDiagnosticReport{Kind: DocumentDiagnosticReportKindUnchanged, ResultId: resultId}
+}
+
+// https://microsoft.github.io/language-server-protocol/specifications/lsp/3.17/specification/#workspace_diagnostic
+type WorkspaceDiagnosticParams struct {
+	// Result ids of the diagnostics the client already holds
+	PreviousResultIds []PreviousResultId `json:"previousResultIds"`
+	// If set, reports are streamed through $/progress instead of the response
+	PartialResultToken any `json:"partialResultToken,omitempty"`
+	// Not part of the protocol: diagnose as of this yyyy-mm-dd date instead of today
+	AsOf string `json:"asOf,omitempty"`
+}
+
+type PreviousResultId struct {
+	Uri   string `json:"uri"`
+	Value string `json:"value"`
+}
+
+type WorkspaceDiagnosticReport struct {
+	Items []WorkspaceDocumentDiagnosticReport `json:"items"`
+}
+
+// A full or unchanged report of one document, outside of any textDocument request.
+type WorkspaceDocumentDiagnosticReport struct {
+	Kind     DocumentDiagnosticReportKind `json:"kind"`
+	ResultId string                       `json:"resultId,omitempty"`
+	// Absent from unchanged reports
+	Items []Diagnostic `json:"items,omitzero"`
+	Uri   string       `json:"uri"`
+	// Version of the open document, null for files read from disk
+	Version *float64 `json:"version"`
+}
+
+func NewWorkspaceDocumentDiagnosticReport(uri string, report SingleDocumentDiagnosticReport) WorkspaceDocumentDiagnosticReport {
+	return WorkspaceDocumentDiagnosticReport{
+		Kind:     report.Kind,
+		ResultId: report.ResultId,
+		Items:    report.Items,
+		Uri:      uri,
+	}
+}
+
+func NewWorkspaceDiagnosticResponse(id int, items []WorkspaceDocumentDiagnosticReport) *workspaceDiagnosticResponse {
+	if items == nil {
+		items = []WorkspaceDocumentDiagnosticReport{}
+	}
+	return &workspaceDiagnosticResponse{
+		Jsonrpc: JsonRPCVersion,
+		ID:      id,
+		Result:  WorkspaceDiagnosticReport{Items: items},
+	}
 }
 
-type FullDocumentDiagnosticReport struct {
-	Kind  DocumentDiagnosticReportKind `json:"kind"`
-	Items []Diagnostic                 `json:"items"`
+type workspaceDiagnosticResponse struct {
+	Jsonrpc string                    `json:"jsonrpc"`
+	ID      int                       `json:"id"`
+	Result  WorkspaceDiagnosticReport `json:"result"`
+}
+
+// https://microsoft.github.io/language-server-protocol/specifications/lsp/3.17/specification/#progress
+type ProgressParams struct {
+	Token any `json:"token"`
+	Value any `json:"value"`
 }
 
 func NewPublishDiagnosticsNotfication(params PublishDiagnosticsParams) *PublishDiagnosticsNotification {
@@ -166,13 +353,346 @@ type TextDocument struct {
 
 type DocumentDiagnosticsParams struct {
 	TextDocument TextDocument `json:"textDocument"`
+	// Result id of the diagnostics the client holds for this document, if any
+	PreviousResultId string `json:"previousResultId,omitempty"`
+	// Not part of the protocol: diagnose as of this yyyy-mm-dd date instead of today
+	AsOf string `json:"asOf,omitempty"`
 }
 
 type CollectParams struct {
 	CurrentDocumentUri string `json:"currentDocumentUri"`
+	// Collect what is due on this yyyy-mm-dd date instead of today
+	AsOf string `json:"asOf,omitempty"`
+	// Leeches are left out unless asked for
+	IncludeLeeches bool `json:"includeLeeches,omitempty"`
+}
+
+type ForecastParams struct {
+	// How many days to forecast, 14 if not set
+	Days int `json:"days,omitempty"`
+	// Forecast from this yyyy-mm-dd date instead of today
+	AsOf string `json:"asOf,omitempty"`
+}
+
+type StatsParams struct {
+	// Compute as of this yyyy-mm-dd date instead of today
+	AsOf string `json:"asOf,omitempty"`
+	// How many days the heatmap covers, ending today, 365 if not set
+	HeatmapDays int `json:"heatmapDays,omitempty"`
+}
+
+// Learning statistics of the whole forest, result of vocab/stats.
+type VocabStats struct {
+	TotalWords int `json:"totalWords"`
+	// Map of language code and how many distinct words were written down in it
+	WordsPerLanguage map[string]int `json:"wordsPerLanguage"`
+	// Only days with at least one new word, oldest first
+	NewWordsPerDay []DailyCount `json:"newWordsPerDay"`
+	// Only days with at least one review, oldest first
+	ReviewsPerDay []DailyCount `json:"reviewsPerDay"`
+	// How many times each grade, from 0 to 5, was given
+	GradeDistribution []int `json:"gradeDistribution"`
+	// Days in a row with any activity, up to today or yesterday
+	CurrentStreak int `json:"currentStreak"`
+	LongestStreak int `json:"longestStreak"`
+	// Average over every word of the easiness factor after its last review
+	AverageEasinessFactor float64 `json:"averageEasinessFactor"`
+	// Words whose interval reached MatureInterval days, the rest are young
+	MatureWords int `json:"matureWords"`
+	YoungWords  int `json:"youngWords"`
+	// New words and reviews of every day of the heatmap, including days without any, oldest first
+	Heatmap []DailyCount `json:"heatmap"`
+}
+
+func NewStatsResponse(id int, stats VocabStats) *statsResponse {
+	return &statsResponse{Jsonrpc: JsonRPCVersion, ID: id, Result: stats}
+}
+
+type statsResponse struct {
+	Jsonrpc string     `json:"jsonrpc"`
+	ID      int        `json:"id"`
+	Result  VocabStats `json:"result"`
+}
+
+type ExportParams struct {
+	// json, csv or anki
+	Format string `json:"format"`
+}
+
+type ExportResult struct {
+	Format string `json:"format"`
+	// The whole export, ready to be written to a file
+	Content string `json:"content"`
+}
+
+func NewExportResponse(id int, result ExportResult) *exportResponse {
+	return &exportResponse{Jsonrpc: JsonRPCVersion, ID: id, Result: result}
+}
+
+type exportResponse struct {
+	Jsonrpc string       `json:"jsonrpc"`
+	ID      int          `json:"id"`
+	Result  ExportResult `json:"result"`
+}
+
+type ImportParams struct {
+	// The document the sections are appended to
+	Uri string `json:"uri"`
+	// The CSV or TSV file itself
+	Content string        `json:"content"`
+	Columns ImportColumns `json:"columns"`
+	// Language code of the rows without a lang column
+	Lang string `json:"lang,omitempty"`
+	// Go layout of the date column, "unixms" for Anki review log ids, yyyy-mm-dd if not set
+	DateLayout string `json:"dateLayout,omitempty"`
+	// "\t" or ",", guessed if not set
+	Separator string `json:"separator,omitempty"`
+	// Skip the first row when columns are given by number
+	Header bool `json:"header,omitempty"`
+}
+
+// Columns by header name, or by number starting at 1.
+type ImportColumns struct {
+	Word  string `json:"word"`
+	Lang  string `json:"lang,omitempty"`
+	Date  string `json:"date"`
+	Grade string `json:"grade,omitempty"`
+	// Ease of an Anki review log, read instead of grade
+	Ease string `json:"ease,omitempty"`
+	// Whether the word is graded in both directions, and the grade of producing it
+	TwoWay          string `json:"twoWay,omitempty"`
+	ProductionGrade string `json:"productionGrade,omitempty"`
+}
+
+type TextEdit struct {
+	Range   Range  `json:"range"`
+	NewText string `json:"newText"`
+}
+
+// https://microsoft.github.io/language-server-protocol/specifications/lsp/3.17/specification/#workspaceEdit
+type WorkspaceEdit struct {
+	Changes map[string][]TextEdit `json:"changes"`
+}
+
+func NewWorkspaceEditResponse(id int, edit WorkspaceEdit) *workspaceEditResponse {
+	return &workspaceEditResponse{Jsonrpc: JsonRPCVersion, ID: id, Result: edit}
+}
+
+type workspaceEditResponse struct {
+	Jsonrpc string        `json:"jsonrpc"`
+	ID      int           `json:"id"`
+	Result  WorkspaceEdit `json:"result"`
+}
+
+type Location struct {
+	Uri   string `json:"uri"`
+	Range Range  `json:"range"`
 }
 
-func NewCollectResponse(requestId int, itWords []string, deWords []string) *map[string]any {
+// A word forgotten so many times that reviewing it again as is won't help.
+type Leech struct {
+	Word           string     `json:"word"`
+	Lang           string     `json:"lang"`
+	Lapses         int        `json:"lapses"`
+	EasinessFactor float64    `json:"easinessFactor"`
+	Locations      []Location `json:"locations"`
+}
+
+func NewLeechesResponse(id int, leeches []Leech) *leechesResponse {
+	return &leechesResponse{Jsonrpc: JsonRPCVersion, ID: id, Result: leeches}
+}
+
+type leechesResponse struct {
+	Jsonrpc string  `json:"jsonrpc"`
+	ID      int     `json:"id"`
+	Result  []Leech `json:"result"`
+}
+
+type ClozeParams struct {
+	// Make cards of what is due on this yyyy-mm-dd date instead of today
+	AsOf string `json:"asOf,omitempty"`
+}
+
+// An utterance with a due word blanked out, to fill it back in.
+type Cloze struct {
+	Word string `json:"word"`
+	Lang string `json:"lang"`
+	// The utterance with every word that makes up Word replaced by a blank
+	Sentence string `json:"sentence"`
+	// The blanked words as the utterance has them, in whatever form it uses
+	Answer string `json:"answer"`
+	// Every gloss the word was written with, as a hint
+	Glosses []string `json:"glosses,omitempty"`
+	// Where the utterance is
+	Location Location `json:"location"`
+}
+
+func NewClozeResponse(id int, clozes []Cloze) *clozeResponse {
+	return &clozeResponse{Jsonrpc: JsonRPCVersion, ID: id, Result: clozes}
+}
+
+type clozeResponse struct {
+	Jsonrpc string  `json:"jsonrpc"`
+	ID      int     `json:"id"`
+	Result  []Cloze `json:"result"`
+}
+
+type QuizStartParams struct {
+	// Quiz what is due on this yyyy-mm-dd date instead of today, and date the grades with it
+	AsOf string `json:"asOf,omitempty"`
+	// Leeches are left out unless asked for
+	IncludeLeeches bool `json:"includeLeeches,omitempty"`
+	// Send every card with the latest utterance that uses its word, blanked out
+	Cloze bool `json:"cloze,omitempty"`
+}
+
+type QuizParams struct {
+	QuizId string `json:"quizId"`
+}
+
+type QuizAnswerParams struct {
+	QuizId string `json:"quizId"`
+	// Grade of the current card, from 0 to 5
+	Grade int `json:"grade"`
+}
+
+// A word of a quiz to review, in one direction.
+type QuizCard struct {
+	Word string `json:"word"`
+	Lang string `json:"lang"`
+	// recognition or production
+	Direction string   `json:"direction"`
+	Glosses   []string `json:"glosses,omitempty"`
+	Cloze     *Cloze   `json:"cloze,omitempty"`
+	// How many cards are left after this one
+	Remaining int `json:"remaining"`
+}
+
+func NewQuizCardResponse(id int, card QuizCard) *quizCardResponse {
+	return &quizCardResponse{Jsonrpc: JsonRPCVersion, ID: id, Result: card}
+}
+
+type quizCardResponse struct {
+	Jsonrpc string   `json:"jsonrpc"`
+	ID      int      `json:"id"`
+	Result  QuizCard `json:"result"`
+}
+
+type QuizAnswer struct {
+	// How many cards are left to answer
+	Remaining int `json:"remaining"`
+	// Writes the grades down once the last card is answered
+	Edit *WorkspaceEdit `json:"edit,omitempty"`
+}
+
+func NewQuizAnswerResponse(id int, answer QuizAnswer) *quizAnswerResponse {
+	return &quizAnswerResponse{Jsonrpc: JsonRPCVersion, ID: id, Result: answer}
+}
+
+type quizAnswerResponse struct {
+	Jsonrpc string     `json:"jsonrpc"`
+	ID      int        `json:"id"`
+	Result  QuizAnswer `json:"result"`
+}
+
+type CompletionParams struct {
+	TextDocument TextDocumentIdentifier `json:"textDocument"`
+	Position     Position               `json:"position"`
+}
+
+// https://microsoft.github.io/language-server-protocol/specifications/lsp/3.17/specification/#completionItemKind
+const CompletionItemKindText = 1
+
+type CompletionItem struct {
+	Label  string `json:"label"`
+	Kind   int    `json:"kind,omitempty"`
+	Detail string `json:"detail,omitempty"`
+	// Plain text
+	Documentation string `json:"documentation,omitempty"`
+}
+
+type CompletionList struct {
+	// More items may come as the word gets longer
+	IsIncomplete bool             `json:"isIncomplete"`
+	Items        []CompletionItem `json:"items"`
+}
+
+func NewCompletionResponse(id int, list CompletionList) *completionResponse {
+	return &completionResponse{Jsonrpc: JsonRPCVersion, ID: id, Result: list}
+}
+
+type completionResponse struct {
+	Jsonrpc string         `json:"jsonrpc"`
+	ID      int            `json:"id"`
+	Result  CompletionList `json:"result"`
+}
+
+type CodeActionParams struct {
+	TextDocument TextDocumentIdentifier `json:"textDocument"`
+	Range        Range                  `json:"range"`
+	Context      CodeActionContext      `json:"context"`
+}
+
+type CodeActionContext struct {
+	// Diagnostics the client shows over the range
+	Diagnostics []Diagnostic `json:"diagnostics"`
+}
+
+const CodeActionKindQuickFix = "quickfix"
+
+// https://microsoft.github.io/language-server-protocol/specifications/lsp/3.17/specification/#codeAction
+type CodeAction struct {
+	Title string `json:"title"`
+	Kind  string `json:"kind"`
+	// Diagnostics the action resolves
+	Diagnostics []Diagnostic  `json:"diagnostics,omitempty"`
+	IsPreferred bool          `json:"isPreferred,omitempty"`
+	Edit        WorkspaceEdit `json:"edit"`
+}
+
+func NewCodeActionResponse(id int, actions []CodeAction) *codeActionResponse {
+	if actions == nil {
+		actions = []CodeAction{}
+	}
+	return &codeActionResponse{Jsonrpc: JsonRPCVersion, ID: id, Result: actions}
+}
+
+type codeActionResponse struct {
+	Jsonrpc string       `json:"jsonrpc"`
+	ID      int          `json:"id"`
+	Result  []CodeAction `json:"result"`
+}
+
+type SemanticTokensParams struct {
+	TextDocument TextDocumentIdentifier `json:"textDocument"`
+}
+
+// Tokens of a document, five integers each: line, start character, length, type and modifiers.
+// Lines and start characters are relative to the previous token.
+//
+// https://microsoft.github.io/language-server-protocol/specifications/lsp/3.17/specification/#textDocument_semanticTokens
+type SemanticTokens struct {
+	Data []int `json:"data"`
+}
+
+func NewSemanticTokensResponse(id int, tokens SemanticTokens) *semanticTokensResponse {
+	return &semanticTokensResponse{Jsonrpc: JsonRPCVersion, ID: id, Result: tokens}
+}
+
+type semanticTokensResponse struct {
+	Jsonrpc string         `json:"jsonrpc"`
+	ID      int            `json:"id"`
+	Result  SemanticTokens `json:"result"`
+}
+
+type DailyCount struct {
+	// yyyy-mm-dd
+	Date  string `json:"date"`
+	Count int    `json:"count"`
+}
+
+// Words due for review, and apart from them the words due for producing them from their meaning.
+func NewCollectResponse(requestId int, itWords []string, deWords []string, itProduction []string, deProduction []string) *map[string]any {
 	return NewGenericResponse(
 		requestId,
 		map[string]any{
@@ -180,6 +700,10 @@ func NewCollectResponse(requestId int, itWords []string, deWords []string) *map[
 				"it": itWords,
 				"de": deWords,
 			},
+			"production": map[string]any{
+				"it": itProduction,
+				"de": deProduction,
+			},
 		},
 	)
 }
@@ -188,6 +712,15 @@ type Diagnostic struct {
 	Range    Range               `json:"range"`
 	Message  string              `json:"message,omitempty"`
 	Severity DiagnosticsSeverity `json:"severity"`
+	// Tells apart diagnostics of the same severity, such as leeches
+	Code string `json:"code,omitempty"`
+	// Other places that take part in the diagnostic, such as earlier occurrences of a word
+	RelatedInformation []DiagnosticRelatedInformation `json:"relatedInformation,omitempty"`
+}
+
+type DiagnosticRelatedInformation struct {
+	Location Location `json:"location"`
+	Message  string   `json:"message"`
 }
 
 func MakeDiagnostics(message string, line int, startPos int, endPos int, level DiagnosticsSeverity) *Diagnostic {
@@ -6,17 +6,24 @@ import (
 	"os"
 	"os/signal"
 	"syscall"
+	"vocab/cli"
 	"vocab/harvester"
 	"vocab/lib"
 	"vocab/vocabulary/forest"
 )
 
 func main() {
-	print("Starting vocab-ls...\n")
-
 	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
 	defer stop()
 
+	if len(os.Args) > 1 && cli.IsCommand(os.Args[1]) {
+		code := cli.Run(ctx, os.Args[1:], os.Stdout, os.Stderr)
+		stop()
+		os.Exit(code)
+	}
+
+	print("Starting vocab-ls...\n")
+
 	inputReader := lib.NewInputReader(os.Stdin)
 	outputWriter := lib.NewOutputWriter(os.Stdout)
 	logger := lib.NewLogger(os.Stderr)
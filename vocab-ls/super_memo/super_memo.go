@@ -1,6 +1,10 @@
 package super_memo
 
-import "math"
+import (
+	"math"
+	"slices"
+	"time"
+)
 
 const (
 	MemoBlackout            int = 0 // 0 - complete blackout
@@ -13,25 +17,59 @@ const (
 
 const InitialEasinessFactor = 2.5
 
+// The tunable constants of sm2.
+type Parameters struct {
+	InitialEasinessFactor float64
+	// The easiness factor never drops below this.
+	MinimumEasinessFactor float64
+	// Interval in days after the first correct response.
+	FirstInterval float64
+	// Interval in days after the second correct response in a row.
+	SecondInterval float64
+	// Anki-style learning steps: a word graded below 3 comes back after the first step, and after
+	// each next one it passes, before SM-2 schedules it again. Empty to go straight back to
+	// FirstInterval.
+	LearningSteps []time.Duration
+}
+
+func (p Parameters) Equal(other Parameters) bool {
+	return p.InitialEasinessFactor == other.InitialEasinessFactor &&
+		p.MinimumEasinessFactor == other.MinimumEasinessFactor &&
+		p.FirstInterval == other.FirstInterval &&
+		p.SecondInterval == other.SecondInterval &&
+		slices.Equal(p.LearningSteps, other.LearningSteps)
+}
+
+var DefaultParameters = Parameters{
+	InitialEasinessFactor: InitialEasinessFactor,
+	MinimumEasinessFactor: 1.3,
+	FirstInterval:         1,
+	SecondInterval:        6,
+}
+
 // https://en.wikipedia.org/wiki/SuperMemo
 func Sm2(grade int, repetitionNumber int, interval float64, easinessFactor float64) (int, float64, float64) {
+	return DefaultParameters.Sm2(grade, repetitionNumber, interval, easinessFactor)
+}
+
+func (p Parameters) Sm2(grade int, repetitionNumber int, interval float64, easinessFactor float64) (int, float64, float64) {
 	if grade >= 3 {
 		switch repetitionNumber {
 		case 0:
-			interval = 1
+			interval = p.FirstInterval
 		case 1:
-			interval = 6
+			interval = p.SecondInterval
 		default:
 			interval = math.Round(interval * easinessFactor)
 		}
 		repetitionNumber++
 	} else {
 		repetitionNumber = 0
-		interval = 1
+		interval = p.FirstInterval
 	}
 
 	newFactor := easinessFactor + (0.1-(5-float64(grade)))*(0.08+(5-float64(grade))*0.02)
-	easinessFactor = math.Max(1.3, newFactor)
+	easinessFactor = math.Max(p.MinimumEasinessFactor, newFactor)
 
 	return repetitionNumber, interval, easinessFactor
 }
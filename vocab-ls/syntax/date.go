@@ -1,13 +1,32 @@
 package syntax
 
+import "regexp"
+
 const (
-	DateLength                      = len("xx/xx/xxxx")
-	DateSlashFirstPosition          = 2
-	DateSlashSecondPostion          = 5
 	LanguageIdentifierLength        = len("(xx)")
 	LanguageIdentifierLeftParenPos  = 0
 	LanguageIdentifierRightParenPos = 3
 	HTMLCommentOpeningLength        = len("<!--")
 	HTMLCommentClosingLength        = len("-->")
 	DateLayout                      = "02/01/2006"
+	// Not a go layout: pick the layout of each date by its shape, and the order of day and month
+	// of slashed dates by the other dates of the document.
+	AutoDateLayout = "auto"
 )
+
+// Every shape of date the scanner recognizes: dd/mm/yyyy or mm/dd/yyyy, dd.mm.yyyy and yyyy-mm-dd.
+var DateExpression = regexp.MustCompile(`^(\d{2}/\d{2}/\d{4}|\d{2}\.\d{2}\.\d{4}|\d{4}-\d{2}-\d{2})$`)
+
+// Names of the days of the week, lowercased, that may come before a date as in `Mon, 20/05/2025`.
+// English, German, Italian and French, in full and abbreviated.
+var Weekdays = map[string]struct{}{
+	"monday": {}, "tuesday": {}, "wednesday": {}, "thursday": {}, "friday": {}, "saturday": {}, "sunday": {},
+	"mon": {}, "tue": {}, "tues": {}, "wed": {}, "thu": {}, "thur": {}, "thurs": {}, "fri": {}, "sat": {}, "sun": {},
+	"montag": {}, "dienstag": {}, "mittwoch": {}, "donnerstag": {}, "freitag": {}, "samstag": {}, "sonnabend": {}, "sonntag": {},
+	"mo": {}, "di": {}, "mi": {}, "do": {}, "fr": {}, "sa": {}, "so": {},
+	"lunedì": {}, "martedì": {}, "mercoledì": {}, "giovedì": {}, "venerdì": {}, "sabato": {}, "domenica": {},
+	"lunedi": {}, "martedi": {}, "mercoledi": {}, "giovedi": {}, "venerdi": {},
+	"lun": {}, "mar": {}, "mer": {}, "gio": {}, "ven": {}, "sab": {}, "dom": {},
+	"lundi": {}, "mardi": {}, "mercredi": {}, "jeudi": {}, "vendredi": {}, "samedi": {}, "dimanche": {},
+	"jeu": {}, "sam": {}, "dim": {},
+}
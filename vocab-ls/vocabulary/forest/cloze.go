@@ -0,0 +1,166 @@
+package forest
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+	"vocab/lib"
+	lsproto "vocab/lsp"
+	"vocab/vocabulary/languages"
+	"vocab/vocabulary/parser"
+)
+
+// What a blanked word of a cloze is replaced with.
+const clozeBlank = "____"
+
+// A cloze of every word due on day, made of the latest utterance that uses it. Words no utterance
+// uses are left out. The most overdue first.
+func (c *Snapshot) Clozes(day time.Time) []lsproto.Cloze {
+	clozes := []lsproto.Cloze{}
+	for _, due := range c.dueFruits(day) {
+		if cloze, found := c.cloze(due.fruit); found {
+			clozes = append(clozes, cloze)
+		}
+	}
+	return clozes
+}
+
+// A fruit due for review, in one direction or both.
+type dueFruit struct {
+	fruit       *WordFruit
+	recognition bool
+	production  bool
+}
+
+// Every fruit due on day, the most overdue first.
+func (c *Snapshot) dueFruits(day time.Time) []dueFruit {
+	at := lib.Day(day)
+	if at.Equal(c.Today()) {
+		at = c.Now()
+	}
+
+	dues := []dueFruit{}
+	for key, entry := range c.words {
+		fruit := entry.Fruit(key)
+		due := dueFruit{
+			fruit:       fruit,
+			recognition: isDue(fruit, at),
+			production:  fruit.Production != nil && isDue(fruit.Production, at),
+		}
+		if due.recognition || due.production {
+			dues = append(dues, due)
+		}
+	}
+
+	slices.SortFunc(dues, func(a, b dueFruit) int {
+		return cmp.Or(
+			a.since().Compare(b.since()),
+			cmp.Compare(a.fruit.Lang.Code(), b.fruit.Lang.Code()),
+			cmp.Compare(a.fruit.Text, b.fruit.Text),
+		)
+	})
+	return dues
+}
+
+// When the earliest of the directions due fell due.
+func (d dueFruit) since() time.Time {
+	if !d.recognition {
+		return d.fruit.Production.Due
+	}
+	if d.production && d.fruit.Production.Due.Before(d.fruit.Due) {
+		return d.fruit.Production.Due
+	}
+	return d.fruit.Due
+}
+
+func isDue(fruit *WordFruit, at time.Time) bool {
+	if fruit.Learning {
+		return !fruit.Due.After(at)
+	}
+	return fruitToRemainingDays(fruit, lib.Day(at)) <= 0
+}
+
+// The cloze of the latest utterance of a section of fruit that uses it.
+func (c *Snapshot) cloze(fruit *WordFruit) (lsproto.Cloze, bool) {
+	searched := map[*parser.VocabularySection]struct{}{}
+	for _, word := range slices.Backward(fruit.Words) {
+		section := word.Parent.Parent
+		if _, done := searched[section]; done {
+			continue
+		}
+		searched[section] = struct{}{}
+
+		for _, utterance := range slices.Backward(section.Utterance) {
+			blanks := c.clozeBlanks(fruit, word, utterance.Text)
+			if len(blanks) == 0 {
+				continue
+			}
+			sentence, answer := blankOut(utterance.Text, blanks)
+			return lsproto.Cloze{
+				Word:     fruit.Text,
+				Lang:     fruit.Lang.Code(),
+				Sentence: sentence,
+				Answer:   answer,
+				Glosses:  fruit.Glosses,
+				Location: lsproto.Location{
+					Uri: section.Uri,
+					Range: lsproto.Range{
+						Start: lsproto.Position{Line: utterance.Line, Character: utterance.Start},
+						End:   lsproto.Position{Line: utterance.Line, Character: utterance.End},
+					},
+				},
+			}, true
+		}
+	}
+	return lsproto.Cloze{}, false
+}
+
+// The words of text that make up fruit. Articles are never part of them, and inflected forms are
+// recognized by the lemma list of the language, or failing that by their stem.
+func (c *Snapshot) clozeBlanks(fruit *WordFruit, word *parser.Word, text string) []languages.Token {
+	if word.Literally {
+		lowered := strings.ToLower(text)
+		at := strings.Index(lowered, strings.ToLower(word.Text))
+		if at < 0 {
+			return nil
+		}
+		start := utf8.RuneCountInString(lowered[:at])
+		return []languages.Token{{Text: word.Text, Start: start, End: start + utf8.RuneCountInString(word.Text)}}
+	}
+
+	same := c.samePart(fruit.Lang)
+	inflected := func(part string, token string) bool {
+		return same(part, token) || sameStem(part, token)
+	}
+	parts := strings.Fields(strings.ToLower(fruit.Text))
+	tokens := phraseTokens(fruit.Lang, text)
+	if fruit.Lang == parser.Deutsch {
+		return matchAnyOrder(parts, tokens, inflected)
+	}
+	return matchInOrder(parts, tokens, inflected)
+}
+
+// Whether token is part with another ending, as casa and case or Haus and Hauses.
+func sameStem(part string, token string) bool {
+	stem := strings.TrimRight(part, "aeiouàèéìòù")
+	length := utf8.RuneCountInString(stem)
+	return length >= 3 && strings.HasPrefix(token, stem) && utf8.RuneCountInString(token) <= length+2
+}
+
+// Text with every blank replaced, and the words replaced, in order.
+func blankOut(text string, blanks []languages.Token) (string, string) {
+	runes := []rune(text)
+	var sentence strings.Builder
+	answers := []string{}
+	at := 0
+	for _, blank := range blanks {
+		sentence.WriteString(string(runes[at:blank.Start]))
+		sentence.WriteString(clozeBlank)
+		answers = append(answers, string(runes[blank.Start:blank.End]))
+		at = blank.End
+	}
+	sentence.WriteString(string(runes[at:]))
+	return sentence.String(), strings.Join(answers, " ")
+}
@@ -0,0 +1,50 @@
+package forest
+
+import (
+	"testing"
+	"time"
+	"vocab/lib"
+	test "vocab/vocab_testing"
+)
+
+func TestClozes_ShouldBlankDueWordsOutOfTheirLatestUtterance(t *testing.T) {
+	forest := NewForest(t.Context(), func(any) {}).
+		WithClock(lib.FixedClock{Time: time.Date(2025, time.June, 5, 12, 0, 0, 0, time.Local)})
+	forest.Plant("a", test.TrimLines(`
+		01/06/2025
+		> (de) der Hund, sich Sorgen machen
+		Der Hund schläft.
+		Ich mache mir keine Sorgen.
+		02/06/2025
+		> (it) la casa(5), `+"`d'accordo`"+`
+		Le case sono belle, siamo D'accordo.
+		03/06/2025
+		>> (de) der Hund(2)
+		Sie sieht den Hund nicht.
+		Wir sehen die Hunde.
+	`), nil)
+
+	clozes := forest.Clozes(time.Date(2025, time.June, 5, 0, 0, 0, 0, time.UTC))
+	test.Expect(t, 4, len(clozes))
+
+	// due since the 2nd, then the 3rd
+	test.Expect(t, "sorgen machen", clozes[0].Word)
+	test.Expect(t, "Ich ____ mir keine ____.", clozes[0].Sentence)
+	test.Expect(t, "mache Sorgen", clozes[0].Answer)
+	test.Expect(t, 3, clozes[0].Location.Range.Start.Line)
+
+	test.Expect(t, "casa", clozes[1].Word)
+	test.Expect(t, "Le ____ sono belle, siamo D'accordo.", clozes[1].Sentence)
+	test.Expect(t, "case", clozes[1].Answer)
+
+	test.Expect(t, "d'accordo", clozes[2].Word)
+	test.Expect(t, "Le case sono belle, siamo ____.", clozes[2].Sentence)
+	test.Expect(t, "D'accordo", clozes[2].Answer)
+
+	// the latest utterance of the latest section, article left in place
+	test.Expect(t, "Hund", clozes[3].Word)
+	test.Expect(t, "Wir sehen die ____.", clozes[3].Sentence)
+	test.Expect(t, "Hunde", clozes[3].Answer)
+
+	test.Expect(t, 0, len(forest.Clozes(time.Date(2025, time.June, 1, 0, 0, 0, 0, time.UTC))))
+}
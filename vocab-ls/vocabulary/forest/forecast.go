@@ -0,0 +1,86 @@
+package forest
+
+import (
+	"math"
+	"time"
+	"vocab/lib"
+	"vocab/vocabulary/parser"
+)
+
+// How many words fall due on each of the coming days.
+type Forecast struct {
+	Days []ForecastDay
+	// Same days, if every word due today is reviewed today with a grade of 4
+	Projected []ForecastDay
+}
+
+type ForecastDay struct {
+	Date  time.Time
+	Total ForecastCount
+	// Map of language code and its share of the total
+	Languages map[string]ForecastCount
+}
+
+type ForecastCount struct {
+	Due int
+	// Words that were never reviewed since they were written down
+	New     int
+	Reviews int
+}
+
+// The grade every word due today is assumed to get in Forecast.Projected.
+const ProjectedGrade = 4
+
+// Count the words due on each of the days days starting from day. Overdue words count for day.
+func (c *Snapshot) Forecast(day time.Time, days int) Forecast {
+	day = lib.Day(day)
+	forecast := Forecast{
+		Days:      newForecastDays(day, days),
+		Projected: newForecastDays(day, days),
+	}
+
+	for key, entry := range c.words {
+		fruit := entry.Fruit(key)
+		isNew := len(fruit.Words) == 1
+		remaining := int(math.Max(0, fruitToRemainingDays(fruit, day)))
+		countForecast(forecast.Days, remaining, key.lang, isNew)
+
+		if remaining > 0 {
+			countForecast(forecast.Projected, remaining, key.lang, isNew)
+			continue
+		}
+		sinceLastSeen := day.Sub(lib.Day(fruit.LastSeenDate)).Hours() / 24
+		_, interval, _ := entry.parameters.Sm2(ProjectedGrade, fruit.RepetitionNumber, sinceLastSeen, fruit.EasinessFactor)
+		countForecast(forecast.Projected, int(math.Ceil(interval)), key.lang, false)
+	}
+
+	return forecast
+}
+
+func newForecastDays(day time.Time, days int) []ForecastDay {
+	forecastDays := make([]ForecastDay, max(days, 0))
+	for i := range forecastDays {
+		forecastDays[i] = ForecastDay{
+			Date:      day.AddDate(0, 0, i),
+			Languages: make(map[string]ForecastCount),
+		}
+	}
+	return forecastDays
+}
+
+func countForecast(days []ForecastDay, index int, lang parser.Language, isNew bool) {
+	if index >= len(days) {
+		return
+	}
+	add := func(count ForecastCount) ForecastCount {
+		count.Due++
+		if isNew {
+			count.New++
+		} else {
+			count.Reviews++
+		}
+		return count
+	}
+	days[index].Total = add(days[index].Total)
+	days[index].Languages[lang.Code()] = add(days[index].Languages[lang.Code()])
+}
@@ -0,0 +1,37 @@
+package forest
+
+import (
+	"testing"
+	"time"
+	"vocab/lib"
+	test "vocab/vocab_testing"
+)
+
+func TestForecast_ShouldCountDueWordsPerDayAndLanguage(t *testing.T) {
+	forest := NewForest(t.Context(), func(any) {}).WithClock(lib.FixedClock{Time: time.Date(2025, time.June, 9, 12, 0, 0, 0, time.Local)})
+	forest.Plant("a", test.TrimLines(`
+		01/06/2025
+		> (it) casa, mostrare(5)
+		> (de) der Hund
+		08/06/2025
+		>> (it) casa(4)
+		> (it) gatto(5)
+	`), nil)
+
+	forecast := forest.Forecast(forest.Snapshot().Today(), 3)
+	test.Expect(t, 3, len(forecast.Days))
+
+	today := forecast.Days[0]
+	test.Expect(t, "2025-06-09", today.Date.Format(time.DateOnly))
+	// casa is due after its review, mostrare and der Hund are overdue, gatto is due today
+	test.Expect(t, 4, today.Total.Due)
+	test.Expect(t, 3, today.Total.New)
+	test.Expect(t, 1, today.Total.Reviews)
+	test.Expect(t, 3, today.Languages["it"].Due)
+	test.Expect(t, 1, today.Languages["de"].Due)
+
+	// graded 4 today, der Hund starts over and comes back tomorrow, the others in 6 days
+	test.Expect(t, 0, forecast.Projected[0].Total.Due)
+	test.Expect(t, 1, forecast.Projected[1].Total.Due)
+	test.Expect(t, 1, forecast.Projected[1].Languages["de"].Due)
+}
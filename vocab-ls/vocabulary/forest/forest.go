@@ -2,37 +2,71 @@ package forest
 
 import (
 	"context"
-	"fmt"
-	"maps"
-	"math"
 	"slices"
 	"sync"
+	"sync/atomic"
 	"time"
+	"vocab/config"
+	"vocab/dictionary"
 	lib "vocab/lib"
 	lsproto "vocab/lsp"
 	"vocab/vocabulary/parser"
 )
 
 // The compiler
+//
+// Every plant produces a new immutable Plot, which is swapped into a new Snapshot of the whole
+// forest. Readers work on whichever snapshot was current when they started and never block
+// planting.
 type Forest struct {
-	ctx context.Context
-	// Map of document uri and the associated diagnostics from parser
-	parsingDiagnostics map[string][]*lsproto.Diagnostic
-	// Map of document uri and the associated trees
-	trees        map[string]*WordTree
-	log          func(any)
-	pool         *lib.GoWorkerPool
-	harvestMutex sync.Mutex
+	ctx      context.Context
+	log      func(any)
+	pool     *lib.GoWorkerPool
+	snapshot atomic.Pointer[Snapshot]
+
+	// Serializes writers, readers go through snapshot
+	writeMutex sync.Mutex
+	// Map of document uri and the sequence number of the latest plant or removal started on it.
+	// Plants finishing after a newer one started are dropped.
+	sequences    map[string]uint64
+	nextSequence uint64
 }
 
 func NewForest(ctx context.Context, log func(any)) *Forest {
-	return &Forest{
-		parsingDiagnostics: make(map[string][]*lsproto.Diagnostic),
-		trees:              make(map[string]*WordTree),
-		ctx:                ctx,
-		log:                log,
-		pool:               lib.NewGoWorkerPool(ctx),
+	forest := &Forest{
+		ctx:       ctx,
+		log:       log,
+		pool:      lib.NewGoWorkerPool(ctx),
+		sequences: make(map[string]uint64),
 	}
+	forest.snapshot.Store(newSnapshot(config.Default(), lib.SystemClock{}))
+	return forest
+}
+
+// The current state of the forest. It never changes, later plants produce a new snapshot.
+func (c *Forest) Snapshot() *Snapshot {
+	return c.snapshot.Load()
+}
+
+// Wait for every pending plant, then return the latest snapshot.
+func (c *Forest) Settled() *Snapshot {
+	c.pool.WaitAll()
+	return c.Snapshot()
+}
+
+// Replace the current snapshot by what update makes of a copy of it.
+//
+// Must hold writeMutex.
+func (c *Forest) commit(update func(next *Snapshot)) {
+	next := c.snapshot.Load().next()
+	update(next)
+	c.snapshot.Store(next)
+}
+
+func (c *Forest) startSequence(documentUri string) uint64 {
+	c.nextSequence++
+	c.sequences[documentUri] = c.nextSequence
+	return c.nextSequence
 }
 
 // Create or replace tree associated with documentUri and merge it back to the global tree.
@@ -41,134 +75,204 @@ func NewForest(ctx context.Context, log func(any)) *Forest {
 //
 // This method spawns a new thread if available and parse the given file.
 func (c *Forest) Plant(documentUri string, text string, changeRange *lsproto.Range) *Forest {
+	c.writeMutex.Lock()
+	sequence := c.startSequence(documentUri)
+	dateLayout := c.snapshot.Load().config.DateLayout()
+	c.writeMutex.Unlock()
+
 	c.pool.Run(documentUri, func() {
-		scanner := parser.NewScanner(text)
-		parser := parser.NewParser(c.ctx, documentUri, scanner, c.log)
+		source := text
+		if parser.IsMarkdown(documentUri) {
+			source = parser.MaskMarkdown(text)
+		}
+		scanner := parser.NewScanner(source)
+		parser := parser.NewParser(c.ctx, documentUri, scanner, c.log).SetDateLayout(dateLayout)
 		parser.Parse()
 
-		c.parsingDiagnostics[documentUri] = []*lsproto.Diagnostic{}
+		diagnostics := []*lsproto.Diagnostic{}
 		for _, section := range parser.Ast.Sections {
-			c.parsingDiagnostics[documentUri] = append(c.parsingDiagnostics[documentUri], section.Diagnostics...)
+			diagnostics = append(diagnostics, section.Diagnostics...)
+		}
+		plot := &Plot{
+			Uri:         documentUri,
+			Text:        text,
+			Tree:        AstToWordTree(parser.Ast),
+			Diagnostics: diagnostics,
+			Sections:    parser.Ast.Sections,
 		}
 
-		c.trees[documentUri] = AstToWordTree(parser.Ast)
+		c.writeMutex.Lock()
+		defer c.writeMutex.Unlock()
+		if c.sequences[documentUri] != sequence {
+			// stale, a newer plant or removal of this document started meanwhile
+			return
+		}
+		c.commit(func(next *Snapshot) {
+			next.replant(documentUri, plot)
+		})
 	})
 	return c
 }
 
-func (c *Forest) Remove(documentUri string) {
-	c.pool.Run(documentUri, func() {
-		c.trees[documentUri] = nil
+// Tell the day by clock instead of the system clock.
+func (c *Forest) WithClock(clock lib.Clock) *Forest {
+	c.writeMutex.Lock()
+	defer c.writeMutex.Unlock()
+
+	c.commit(func(next *Snapshot) {
+		next.clock = clock
 	})
+	return c
 }
 
-type HarvestedDiagnostic struct {
-	Diagnostic lsproto.Diagnostic
-	Word       string
-	Lang       parser.Language
+// Tell inflected forms with lemmas, a map of language and its lemma list, instead of the ones
+// told so far.
+func (c *Forest) WithLemmas(lemmas map[parser.Language]*dictionary.Lemmas) *Forest {
+	c.writeMutex.Lock()
+	defer c.writeMutex.Unlock()
+
+	c.commit(func(next *Snapshot) {
+		next.lemmas = lemmas
+		// same fruits, the warnings of every document may differ though
+		next.base = nil
+	})
+	return c
 }
 
-// Based on the built tree, compile tree into diagnostics.
-func (c *Forest) Harvest() map[string][]HarvestedDiagnostic {
+// Apply cfg to the whole forest, replanting every tree if cfg changes how files are parsed.
+func (c *Forest) Configure(cfg config.Config) *Forest {
 	c.pool.WaitAll()
-	c.harvestMutex.Lock()
-	defer c.harvestMutex.Unlock()
+	c.writeMutex.Lock()
+	previous := c.snapshot.Load().config
+	replant := previous.DateFormat != cfg.DateFormat
+	c.commit(func(next *Snapshot) {
+		next.config = cfg
+		if !previous.Parameters().Equal(cfg.Parameters()) || !slices.Equal(previous.TwoWay, cfg.TwoWay) {
+			next.regrow()
+		} else if previous.Diagnostics != cfg.Diagnostics {
+			// same fruits, every diagnostic may differ though
+			next.base = nil
+		}
+	})
+	plots := c.snapshot.Load().plots
+	c.writeMutex.Unlock()
 
-	mergedTree := NewWordTree()
-	for _, tree := range c.trees {
-		mergedTree.Graft(tree)
+	if replant {
+		for uri, plot := range plots {
+			c.Plant(uri, plot.Text, nil)
+		}
 	}
-	fruits := mergedTree.Harvest()
+	return c
+}
 
-	diags := make(map[string][]HarvestedDiagnostic)
-	for uri := range c.trees {
-		diags[uri] = []HarvestedDiagnostic{}
-	}
+func (c *Forest) Config() config.Config {
+	return c.Snapshot().config
+}
 
-	addDiagToAllWordPositions := func(timeRemaining float64, severitiy lsproto.DiagnosticsSeverity, fruit *WordFruit) {
-		for _, word := range fruit.Words {
-			message := func() string {
-				if timeRemaining == 0 {
-					return "Review now!"
-				}
-				// can keep this for hover action
-				if timeRemaining > 0 {
-					return ""
-				}
-				return fmt.Sprintf("%d days past deadline", int(math.Ceil(timeRemaining*-1)))
-			}()
-			if message == "" {
-				continue
-			}
+func (c *Forest) Remove(documentUri string) {
+	c.writeMutex.Lock()
+	defer c.writeMutex.Unlock()
 
-			err := lsproto.MakeDiagnostics(
-				message,
-				word.Line,
-				word.Start,
-				word.End,
-				severitiy,
-			)
-
-			diags[word.Uri()] = append(diags[word.Uri()], HarvestedDiagnostic{
-				Lang:       fruit.Lang,
-				Diagnostic: *err,
-				Word:       fruit.Text,
-			})
-		}
-	}
+	c.startSequence(documentUri)
+	c.commit(func(next *Snapshot) {
+		next.replant(documentUri, nil)
+	})
+}
 
-	for _, fruit := range fruits {
-		severity, remainingDays := func() (lsproto.DiagnosticsSeverity, float64) {
-			remainingDays := fruitToRemainingDays(fruit)
+// Remove every tree planted under folderUri.
+func (c *Forest) RemoveFolder(folderUri string) {
+	c.pool.WaitAll()
+	c.writeMutex.Lock()
+	defer c.writeMutex.Unlock()
 
-			if remainingDays <= 1 {
-				return lsproto.DiagnosticsSeverityError, remainingDays
-			} else if remainingDays < 3 {
-				return lsproto.DiagnosticsSeverityHint, remainingDays
+	c.commit(func(next *Snapshot) {
+		for uri := range next.plots {
+			if isInFolder(uri, folderUri) {
+				c.startSequence(uri)
+				next.replant(uri, nil)
 			}
+		}
+	})
+}
 
-			return lsproto.DiagnosticsSeverityInformation, remainingDays
-		}()
+// Keep the schedule of documents under folderUri apart from the rest of the forest.
+//
+// A word reviewed in an independent folder does not reset the same word in any other folder.
+func (c *Forest) SetIndependent(folderUri string, independent bool) *Forest {
+	c.writeMutex.Lock()
+	defer c.writeMutex.Unlock()
 
-		addDiagToAllWordPositions(remainingDays, severity, fruit)
+	if _, exists := c.snapshot.Load().independentFolders[folderUri]; exists == independent {
+		return c
 	}
-
-	for uri := range c.parsingDiagnostics {
-		for _, diag := range c.parsingDiagnostics[uri] {
-			diags[uri] = append(diags[uri], HarvestedDiagnostic{
-				Diagnostic: *diag,
-				Word:       "",
-			})
+	c.commit(func(next *Snapshot) {
+		if independent {
+			next.independentFolders[folderUri] = struct{}{}
+		} else {
+			delete(next.independentFolders, folderUri)
 		}
-	}
+		next.regrow()
+	})
+	return c
+}
+
+// Wait for every pending plant, then harvest the latest snapshot.
+func (c *Forest) Harvest() map[string][]HarvestedDiagnostic {
+	c.pool.WaitAll()
+	return c.Snapshot().Harvest()
+}
+
+// Like Harvest, as of day instead of today.
+func (c *Forest) HarvestOn(day time.Time) map[string][]HarvestedDiagnostic {
+	c.pool.WaitAll()
+	return c.Snapshot().HarvestOn(day)
+}
+
+// Wait for every pending plant, then forecast the latest snapshot.
+func (c *Forest) Forecast(day time.Time, days int) Forecast {
+	c.pool.WaitAll()
+	return c.Snapshot().Forecast(day, days)
+}
+
+// Wait for every pending plant, then compute the statistics of the latest snapshot.
+func (c *Forest) Stats(day time.Time, heatmapDays int) lsproto.VocabStats {
+	c.pool.WaitAll()
+	return c.Snapshot().Stats(day, heatmapDays)
+}
+
+// Wait for every pending plant, then make clozes of what the latest snapshot has due on day.
+func (c *Forest) Clozes(day time.Time) []lsproto.Cloze {
+	c.pool.WaitAll()
+	return c.Snapshot().Clozes(day)
+}
+
+// Wait for every pending plant, then deal the cards of what the latest snapshot has due on day.
+func (c *Forest) Cards(day time.Time) []Card {
+	c.pool.WaitAll()
+	return c.Snapshot().Cards(day)
+}
 
-	return diags
+// Wait for every pending plant, then list the leeches of the latest snapshot.
+func (c *Forest) Leeches() []lsproto.Leech {
+	c.pool.WaitAll()
+	return c.Snapshot().Leeches()
+}
+
+// Wait for every pending plant, then list every twig of the latest snapshot.
+func (c *Forest) History() []Review {
+	c.pool.WaitAll()
+	return c.Snapshot().History()
 }
 
 func (f *Forest) GetTreesLocations() []string {
-	return slices.Collect(maps.Keys(f.trees))
+	return f.Snapshot().Uris()
 }
 
 // Pick a fruit based on its location in the tree and return its remaining days description
 func (f *Forest) Pick(textDocument string, line int, character int) (string, bool) {
-	if tree, exists := f.trees[textDocument]; exists {
-		picked := tree.Pick(line, character)
-		if picked == nil {
-			return "", false
-		}
-		remaining := fruitToRemainingDays(picked)
-		return fmt.Sprintf("Remaining days: %f", remaining), true
-	}
-	return "", false
+	return f.Snapshot().Pick(textDocument, line, character)
 }
 
-func fruitToRemainingDays(fruit *WordFruit) float64 {
-	if fruit == nil {
-		panic("Fruit is null here...what?!")
-	}
-	interval := math.Ceil(fruit.Interval)
-	deadline := fruit.LastSeenDate.AddDate(0, 0, int(interval))
-	remainingHours := time.Until(deadline).Hours()
-	var remainingDays float64 = remainingHours / 24
-	return remainingDays
+func (f *Forest) PickFruit(textDocument string, line int, character int) (*WordFruit, bool) {
+	return f.Snapshot().PickFruit(textDocument, line, character)
 }
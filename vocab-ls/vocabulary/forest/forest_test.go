@@ -2,10 +2,17 @@ package forest
 
 import (
 	"fmt"
+	"reflect"
+	"strings"
+	"sync"
 	"testing"
 	"time"
+	"vocab/config"
+	"vocab/lib"
+	lsproto "vocab/lsp"
 	"vocab/syntax"
 	test "vocab/vocab_testing"
+	"vocab/vocabulary/parser"
 )
 
 func TestShouldCompile(t *testing.T) {
@@ -182,3 +189,272 @@ func TestErrorStateShouldBeCorrectAfterEditingMultipleFiles(t *testing.T) {
 	test.Expect(t, len(errors["1"]), 1)
 	test.Expect(t, len(errors["2"]), 1)
 }
+
+func TestIndependentFolder_ShouldKeepItsOwnSchedule(t *testing.T) {
+	clock := lib.FixedClock{Time: time.Date(2025, time.June, 10, 18, 0, 0, 0, time.Local)}
+	oldText := "01/01/2025\n> (it) mostrare(5)"
+	newText := "10/06/2025\n>> (it) mostrare(5)"
+
+	// shared: today's review pushes the deadline of the old entry too
+	shared := NewForest(t.Context(), func(any) {}).WithClock(clock)
+	shared.Plant("file:///de/a.vocab", oldText, nil)
+	shared.Plant("file:///it/b.vocab", newText, nil)
+	test.Expect(t, 0, len(shared.Harvest()["file:///de/a.vocab"]))
+
+	// independent: the old entry is on its own and long overdue
+	independent := NewForest(t.Context(), func(any) {}).WithClock(clock)
+	independent.SetIndependent("file:///it", true)
+	independent.Plant("file:///de/a.vocab", oldText, nil)
+	independent.Plant("file:///it/b.vocab", newText, nil)
+	harvested := independent.Harvest()
+	test.Expect(t, 1, len(harvested["file:///de/a.vocab"]))
+	test.Expect(t, 0, len(harvested["file:///it/b.vocab"]))
+}
+
+func TestRemoveFolder_ShouldOnlyRemoveTreesInsideFolder(t *testing.T) {
+	forest := NewForest(t.Context(), func(any) {})
+	forest.Plant("file:///it/a.vocab", "01/01/2025\n> (it) mostrare", nil)
+	forest.Plant("file:///it/nested/b.vocab", "01/01/2025\n> (it) spiegare", nil)
+	forest.Plant("file:///italian/c.vocab", "01/01/2025\n> (it) cosa", nil)
+
+	forest.RemoveFolder("file:///it")
+
+	harvested := forest.Harvest()
+	test.Expect(t, 1, len(harvested))
+	test.Expect(t, true, harvested["file:///italian/c.vocab"] != nil)
+}
+
+func TestConfigure_ShouldApplyDiagnosticsThresholds(t *testing.T) {
+	text := "09/06/2025\n> (it) mostrare(5)"
+
+	forest := NewForest(t.Context(), func(any) {}).WithClock(lib.FixedClock{Time: time.Date(2025, time.June, 10, 8, 0, 0, 0, time.Local)})
+	harvested := forest.Plant("xxx", text, nil).Harvest()
+	test.Expect(t, 1, len(harvested["xxx"]))
+	test.Expect(t, lsproto.DiagnosticsSeverityError, harvested["xxx"][0].Diagnostic.Severity)
+
+	cfg := config.Default()
+	cfg.Diagnostics.ErrorWithinDays = -1
+	harvested = forest.Configure(cfg).Harvest()
+	test.Expect(t, 1, len(harvested["xxx"]))
+	test.Expect(t, lsproto.DiagnosticsSeverityHint, harvested["xxx"][0].Diagnostic.Severity)
+}
+
+func TestConfigure_ShouldReplantWithNewDateFormat(t *testing.T) {
+	forest := NewForest(t.Context(), func(any) {})
+	harvested := forest.Plant("xxx", "12/31/2025\n> (it) mostrare", nil).Harvest()
+	test.Expect(t, parser.MalformedDate, harvested["xxx"][0].Diagnostic.Message)
+
+	cfg := config.Default()
+	cfg.DateFormat = "mm/dd/yyyy"
+	harvested = forest.Configure(cfg).Harvest()
+	for _, diag := range harvested["xxx"] {
+		test.Expect(t, true, diag.Diagnostic.Message != parser.MalformedDate)
+	}
+}
+
+func TestSnapshot_ShouldNotChangeOnceTaken(t *testing.T) {
+	forest := NewForest(t.Context(), func(any) {})
+	forest.Plant("a", "01/01/2025\n> (it) casa", nil).Harvest()
+	before := forest.Snapshot()
+
+	forest.Plant("b", "01/01/2025\n> (it) mostrare", nil).Harvest()
+	after := forest.Snapshot()
+
+	test.Expect(t, 1, len(before.Uris()))
+	test.Expect(t, 2, len(after.Uris()))
+	test.Expect(t, true, after.Version > before.Version)
+}
+
+func TestRemove_ShouldDropPlantsStillRunning(t *testing.T) {
+	forest := NewForest(t.Context(), func(any) {})
+	forest.Plant("a", "01/01/2025\n> (it) casa", nil)
+	forest.Remove("a")
+
+	test.Expect(t, 0, len(forest.Harvest()))
+}
+
+func TestShouldPlantAndReadConcurrently(t *testing.T) {
+	forest := NewForest(t.Context(), func(any) {})
+	var wg sync.WaitGroup
+	for i := range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			uri := fmt.Sprintf("file:///%d.vocab", i)
+			for j := range 20 {
+				forest.Plant(uri, fmt.Sprintf("01/01/2025\n> (it) parola%d", j), nil)
+				forest.Pick(uri, 1, 8)
+				forest.Snapshot().Harvest()
+			}
+		}()
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for range 20 {
+			forest.Harvest()
+			forest.GetTreesLocations()
+		}
+	}()
+	wg.Wait()
+
+	harvested := forest.Harvest()
+	test.Expect(t, 8, len(harvested))
+	// the last plant of each document wins
+	test.Expect(t, "parola19", harvested["file:///0.vocab"][0].Word)
+}
+
+func TestHarvest_ShouldOnlyRecomputeWordsOfTheChangedDocument(t *testing.T) {
+	forest := NewForest(t.Context(), func(any) {})
+	forest.Plant("a", "01/01/2025\n> (it) casa, mostrare", nil)
+	forest.Plant("b", "02/01/2025\n>> (it) mostrare", nil)
+	forest.Plant("c", "01/01/2025\n> (it) gatto", nil)
+	forest.Harvest()
+	before := forest.Snapshot()
+
+	forest.Plant("b", "02/01/2025\n>> (it) mostrare(5)", nil)
+	forest.Harvest()
+	after := forest.Snapshot()
+
+	casa := wordKey{"", parser.Italiano, "casa"}
+	mostrare := wordKey{"", parser.Italiano, "mostrare"}
+	test.Expect(t, true, before.words[casa] == after.words[casa])
+	test.Expect(t, false, before.words[mostrare] == after.words[mostrare])
+	// a shares mostrare with b, c shares nothing
+	_, aDirty := after.dirty["a"]
+	_, cDirty := after.dirty["c"]
+	test.Expect(t, true, aDirty)
+	test.Expect(t, false, cDirty)
+}
+
+func TestHarvest_IncrementalShouldMatchFullHarvest(t *testing.T) {
+	incremental := NewForest(t.Context(), func(any) {})
+	incremental.Plant("a", "01/01/2025\n> (it) casa, mostrare", nil)
+	incremental.Plant("b", "02/01/2025\n>> (it) mostrare(5)", nil)
+	incremental.Harvest()
+	incremental.Plant("b", "02/01/2025\n>> (it) casa(1)", nil)
+	incremental.Remove("c")
+	incremental.Harvest()
+	incremental.Plant("c", "03/01/2025\n>> (it) mostrare(4)", nil)
+
+	full := NewForest(t.Context(), func(any) {})
+	full.Plant("a", "01/01/2025\n> (it) casa, mostrare", nil)
+	full.Plant("b", "02/01/2025\n>> (it) casa(1)", nil)
+	full.Plant("c", "03/01/2025\n>> (it) mostrare(4)", nil)
+
+	test.Expect(t, true, reflect.DeepEqual(full.Harvest(), incremental.Harvest()))
+}
+
+func TestHarvestOn_ShouldTellWhatIsDueThatDay(t *testing.T) {
+	forest := NewForest(t.Context(), func(any) {}).WithClock(lib.FixedClock{Time: time.Date(2025, time.June, 10, 23, 59, 0, 0, time.Local)})
+	// first review: due the next day
+	forest.Plant("xxx", "10/06/2025\n> (it) mostrare(5)", nil)
+
+	test.Expect(t, 0, len(forest.Harvest()["xxx"]))
+
+	due := forest.HarvestOn(time.Date(2025, time.June, 11, 0, 0, 0, 0, time.UTC))["xxx"]
+	test.Expect(t, 1, len(due))
+	test.Expect(t, "Review now!", due[0].Diagnostic.Message)
+
+	late := forest.HarvestOn(time.Date(2025, time.June, 14, 0, 0, 0, 0, time.UTC))["xxx"]
+	test.Expect(t, "3 days past deadline", late[0].Diagnostic.Message)
+
+	// asking about another day leaves today alone
+	test.Expect(t, 0, len(forest.Harvest()["xxx"]))
+}
+
+func TestLearningSteps_ShouldScheduleFailedWordsInHours(t *testing.T) {
+	cfg := config.Default()
+	cfg.Scheduler.LearningSteps = []time.Duration{10 * time.Minute, time.Hour}
+	forest := NewForest(t.Context(), func(any) {}).
+		WithClock(lib.FixedClock{Time: time.Date(2025, time.May, 20, 18, 50, 0, 0, time.Local)}).
+		Configure(cfg)
+
+	forest.Plant("xxx", "20/05/2025 18:30\n> (it) casa(1)", nil)
+	harvested := forest.Harvest()["xxx"]
+	test.Expect(t, 1, len(harvested))
+	test.Expect(t, "Review now!", harvested[0].Diagnostic.Message)
+
+	forest.Plant("xxx", "20/05/2025 18:30\n> (it) casa(1)\n20/05/2025 18:45\n>> (it) casa(4)", nil)
+	harvested = forest.Harvest()["xxx"]
+	test.Expect(t, "Review in 55 minutes", harvested[0].Diagnostic.Message)
+	test.Expect(t, lsproto.DiagnosticsSeverityHint, harvested[0].Diagnostic.Severity)
+
+	// the last step passed, SM-2 takes over with whole days again
+	graduated := NewForest(t.Context(), func(any) {}).
+		WithClock(lib.FixedClock{Time: time.Date(2025, time.May, 20, 21, 0, 0, 0, time.Local)}).
+		Configure(cfg)
+	graduated.Plant("xxx", "20/05/2025 18:30\n> (it) casa(1)\n20/05/2025 18:45\n>> (it) casa(4)\n20/05/2025 20:00\n>> (it) casa(4)", nil)
+	graduated.Harvest()
+	description, _ := graduated.Pick("xxx", 5, 9)
+	test.Expect(t, "Remaining days: 1.000000", description)
+}
+
+func TestLearningSteps_TimedSectionsShouldStayInDaysWithoutSteps(t *testing.T) {
+	forest := NewForest(t.Context(), func(any) {}).WithClock(lib.FixedClock{Time: time.Date(2025, time.May, 21, 8, 0, 0, 0, time.Local)})
+	forest.Plant("xxx", "20/05/2025 18:30\n> (it) casa(1)", nil)
+
+	harvested := forest.Harvest()["xxx"]
+	test.Expect(t, 1, len(harvested))
+	test.Expect(t, "Review now!", harvested[0].Diagnostic.Message)
+}
+
+func TestGloss_ShouldBeMergedWithoutChangingTheSchedule(t *testing.T) {
+	plant := func(a string, b string) *Forest {
+		forest := NewForest(t.Context(), func(any) {}).WithClock(lib.FixedClock{Time: time.Date(2025, time.June, 3, 0, 0, 0, 0, time.Local)})
+		forest.Plant("a", a, nil)
+		forest.Plant("b", b, nil)
+		return forest
+	}
+	glossed := plant("01/06/2025\n> (de) unbestimmt = undetermined", "02/06/2025\n>> (de) unbestimmt :: vague(4)")
+	bare := plant("01/06/2025\n> (de) unbestimmt", "02/06/2025\n>> (de) unbestimmt(4)")
+
+	test.Expect(t, 1, len(glossed.Harvest()["b"]))
+	test.Expect(t, true, reflect.DeepEqual(bare.Harvest(), glossed.Harvest()))
+
+	description, found := glossed.Pick("b", 1, 8)
+	test.Expect(t, true, found)
+	test.Expect(t, "undetermined; vague", strings.Split(description, "\n")[0])
+}
+
+func TestTwoWay_ShouldScheduleRecognitionAndProductionApart(t *testing.T) {
+	forest := NewForest(t.Context(), func(any) {}).WithClock(lib.FixedClock{Time: time.Date(2025, time.June, 3, 0, 0, 0, 0, time.Local)})
+	forest.Plant("xxx", "01/06/2025\n> (de) Haus(5)\n02/06/2025\n>> (de) Haus(5/1)", nil)
+
+	harvested := forest.Harvest()["xxx"]
+	test.Expect(t, 2, len(harvested))
+	for _, diagnostic := range harvested {
+		test.Expect(t, true, diagnostic.Production)
+		test.Expect(t, ProductionCode, diagnostic.Diagnostic.Code)
+		test.Expect(t, "Production: Review now!", diagnostic.Diagnostic.Message)
+	}
+
+	description, _ := forest.Pick("xxx", 3, 9)
+	test.Expect(t, "Recognition: Remaining days: 5.000000\nProduction: Remaining days: 0.000000", description)
+
+	// a single grade counts for both once the language is two-way
+	cfg := config.Default()
+	cfg.TwoWay = []string{"de"}
+	forest.Configure(cfg)
+	forest.Plant("xxx", "01/06/2025\n> (de) Haus(5)\n02/06/2025\n>> (de) Haus(1)", nil)
+
+	harvested = forest.Harvest()["xxx"]
+	test.Expect(t, 4, len(harvested))
+	test.Expect(t, "Recognition: Review now!", harvested[0].Diagnostic.Message)
+	test.Expect(t, "Production: Review now!", harvested[1].Diagnostic.Message)
+}
+
+func TestHarvest_ShouldBeKeptForTheDayUnlessLearningStepsCountMinutes(t *testing.T) {
+	now := time.Date(2025, time.May, 20, 10, 15, 30, 0, time.Local)
+	forest := NewForest(t.Context(), func(any) {}).WithClock(lib.FixedClock{Time: now})
+	forest.Plant("xxx", "19/05/2025\n> (it) casa", nil)
+
+	forest.Harvest()
+	test.Expect(t, lib.Day(now), forest.Snapshot().harvested.Load().at)
+
+	cfg := config.Default()
+	cfg.Scheduler.LearningSteps = []time.Duration{10 * time.Minute}
+	forest.Configure(cfg)
+	forest.Harvest()
+	test.Expect(t, lib.Moment(now).Truncate(time.Minute), forest.Snapshot().harvested.Load().at)
+}
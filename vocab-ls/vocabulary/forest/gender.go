@@ -0,0 +1,147 @@
+package forest
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	lsproto "vocab/lsp"
+	"vocab/vocabulary/languages"
+	"vocab/vocabulary/parser"
+)
+
+// Code of the diagnostics flagging a noun written with articles of different genders.
+const GenderCode = "gender"
+
+// The gender word is written with in lang, Ungendered if its article doesn't tell, it is taken
+// literally or it is a phrase.
+func GenderOf(lang parser.Language, word *parser.Word) languages.Gender {
+	if word.Literally || isPhrase(lang, word) {
+		return languages.Ungendered
+	}
+
+	switch lang {
+	case parser.Italiano:
+		return languages.ItalianGenderOfWord(strings.ToLower(word.Text))
+	case parser.Français:
+		return languages.FrenchGenderOfWord(strings.ToLower(word.Text))
+	default:
+		return languages.GermanGenderOfWord(strings.ToLower(word.Text))
+	}
+}
+
+// Every gender fruit was written with, in the order they were first written.
+func (fruit *WordFruit) Genders() []languages.Gender {
+	genders := []languages.Gender{}
+	for _, word := range fruit.Words {
+		gender := GenderOf(fruit.Lang, word)
+		if gender != languages.Ungendered && !slices.Contains(genders, gender) {
+			genders = append(genders, gender)
+		}
+	}
+	return genders
+}
+
+// What hover says about the gender of fruit, "" if it was never written with a telling article.
+func genderMessage(fruit *WordFruit) string {
+	genders := fruit.Genders()
+	switch len(genders) {
+	case 0:
+		return ""
+	case 1:
+		return fmt.Sprintf("Gender: %s", genders[0])
+	}
+	others := []string{}
+	for _, gender := range genders {
+		if gender != fruit.Gender {
+			others = append(others, string(gender))
+		}
+	}
+	return fmt.Sprintf("Gender: %s, also written as %s", fruit.Gender, strings.Join(others, " and "))
+}
+
+// A warning on every word of fruit in documentUri whose gender disagrees with another occurrence
+// of it, anywhere in its schedule. The disagreeing occurrences come along as related information.
+func genderDiagnostics(fruit *WordFruit, documentUri string) []*lsproto.Diagnostic {
+	if len(fruit.Genders()) < 2 {
+		return nil
+	}
+
+	diags := []*lsproto.Diagnostic{}
+	for _, word := range fruit.Words {
+		gender := GenderOf(fruit.Lang, word)
+		if word.Uri() != documentUri || gender == languages.Ungendered {
+			continue
+		}
+
+		related := []lsproto.DiagnosticRelatedInformation{}
+		others := []string{}
+		for _, other := range fruit.Words {
+			otherGender := GenderOf(fruit.Lang, other)
+			if otherGender == languages.Ungendered || otherGender == gender {
+				continue
+			}
+			related = append(related, lsproto.DiagnosticRelatedInformation{
+				Location: wordLocation(other),
+				Message:  fmt.Sprintf("%s, %s on %s", other.Text, otherGender, other.Parent.Parent.Date.Text),
+			})
+			if !slices.Contains(others, string(otherGender)) {
+				others = append(others, string(otherGender))
+			}
+		}
+
+		warning := lsproto.MakeDiagnostics(
+			fmt.Sprintf("%s is %s here, but %s elsewhere", fruit.Text, gender, strings.Join(others, " and ")),
+			word.Line,
+			word.Start,
+			word.End,
+			lsproto.DiagnosticsSeverityWarning,
+		)
+		warning.Code = GenderCode
+		warning.RelatedInformation = related
+		diags = append(diags, warning)
+	}
+	return diags
+}
+
+// A word of a document along with the gender it is written with there.
+type Noun struct {
+	Word   *parser.Word
+	Gender languages.Gender
+}
+
+// Every word of documentUri written with a telling article, in the order of the document.
+func (c *Snapshot) Nouns(documentUri string) []Noun {
+	plot, exists := c.plots[documentUri]
+	if !exists {
+		return nil
+	}
+
+	nouns := []Noun{}
+	for lang, branch := range plot.Tree.branches {
+		for _, twigs := range branch.twigs {
+			for _, twig := range twigs {
+				gender := GenderOf(parser.Language(lang), twig.word)
+				if gender != languages.Ungendered {
+					nouns = append(nouns, Noun{Word: twig.word, Gender: gender})
+				}
+			}
+		}
+	}
+	slices.SortFunc(nouns, func(a, b Noun) int {
+		if a.Word.Line != b.Word.Line {
+			return a.Word.Line - b.Word.Line
+		}
+		return a.Word.Start - b.Word.Start
+	})
+	return nouns
+}
+
+func wordLocation(word *parser.Word) lsproto.Location {
+	return lsproto.Location{
+		Uri: word.Uri(),
+		Range: lsproto.Range{
+			Start: lsproto.Position{Line: word.Line, Character: word.Start},
+			End:   lsproto.Position{Line: word.Line, Character: word.End},
+		},
+	}
+}
@@ -0,0 +1,73 @@
+package forest
+
+import (
+	"strings"
+	"testing"
+	"time"
+	"vocab/lib"
+	test "vocab/vocab_testing"
+	"vocab/vocabulary/languages"
+)
+
+func TestGender_ShouldFlagNounsWrittenWithDifferentGenders(t *testing.T) {
+	forest := NewForest(t.Context(), func(any) {}).
+		WithClock(lib.FixedClock{Time: time.Date(2025, time.June, 20, 12, 0, 0, 0, time.Local)})
+	forest.Plant("a", test.TrimLines(`
+		01/06/2025
+		> (de) der Berg, das Haus
+	`), nil)
+	forest.Plant("b", test.TrimLines(`
+		02/06/2025
+		>> (de) die Berg, Haus, das Haus
+	`), nil)
+
+	harvested := forest.Harvest()
+	warnings := map[string][]HarvestedDiagnostic{}
+	for uri, diags := range harvested {
+		for _, diag := range diags {
+			if diag.Diagnostic.Code == GenderCode {
+				warnings[uri] = append(warnings[uri], diag)
+			}
+		}
+	}
+	test.Expect(t, 1, len(warnings["a"]), len(warnings["b"]))
+
+	inA := warnings["a"][0].Diagnostic
+	test.Expect(t, "Berg is masculine here, but feminine elsewhere", inA.Message)
+	test.Expect(t, 1, len(inA.RelatedInformation))
+	test.Expect(t, "b", inA.RelatedInformation[0].Location.Uri)
+	test.Expect(t, 1, inA.RelatedInformation[0].Location.Range.Start.Line)
+	test.Expect(t, "die Berg, feminine on 02/06/2025", inA.RelatedInformation[0].Message)
+	test.Expect(t, "Berg is feminine here, but masculine elsewhere", warnings["b"][0].Diagnostic.Message)
+
+	description, _ := forest.Pick("b", 1, 9)
+	test.Expect(t, true, strings.Contains(description, "Gender: feminine, also written as masculine"))
+	description, _ = forest.Pick("a", 1, 19)
+	test.Expect(t, true, strings.Contains(description, "Gender: neuter\n"))
+
+	nouns := forest.Snapshot().Nouns("b")
+	test.Expect(t, 2, len(nouns))
+	test.Expect(t, languages.Feminine, nouns[0].Gender)
+	test.Expect(t, "das Haus", nouns[1].Word.Text)
+
+	forest.Plant("b", test.TrimLines(`
+		02/06/2025
+		>> (de) der Berg
+	`), nil)
+	for _, diags := range forest.Harvest() {
+		for _, diag := range diags {
+			test.Expect(t, false, diag.Diagnostic.Code == GenderCode)
+		}
+	}
+}
+
+func TestGenderOf_ShouldIgnoreTheCaseOfTheArticle(t *testing.T) {
+	forest := NewForest(t.Context(), func(any) {})
+	forest.Plant("a", "01/06/2025\n> (de) Der Hund\n> (it) Un libro", nil)
+	forest.Harvest()
+
+	nouns := forest.Snapshot().Nouns("a")
+	test.Expect(t, 2, len(nouns))
+	test.Expect(t, languages.Masculine, nouns[0].Gender)
+	test.Expect(t, languages.Masculine, nouns[1].Gender)
+}
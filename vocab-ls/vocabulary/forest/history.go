@@ -0,0 +1,74 @@
+package forest
+
+import (
+	"cmp"
+	"slices"
+	"time"
+	"vocab/vocabulary/parser"
+)
+
+// One appearance of a word in a document, as written.
+type Review struct {
+	// The word as written, articles and all
+	Word string
+	// The text the word is scheduled under
+	Text string
+	// What the word means, if it was written with one
+	Gloss string
+	Lang  string
+	Date  time.Time
+	// parser.Ungraded if only the production grade was written, as in word(/3)
+	Grade int
+	// Graded in both directions, as in word(5/3)
+	TwoWay bool
+	// Only meaningful if TwoWay, parser.Ungraded if left out, as in word(5/)
+	ProductionGrade int
+	// Whether it was listed under >> rather than >
+	Reviewed bool
+	Uri      string
+	// Zero based, like every position of the protocol
+	Line int
+	// Every utterance of the section the word appears in
+	Utterances []string
+}
+
+// Every twig of every document, ordered by date, then by where they are written.
+func (c *Snapshot) History() []Review {
+	reviews := []Review{}
+	for _, plot := range c.plots {
+		for lang, branch := range plot.Tree.branches {
+			for text, twigs := range branch.twigs {
+				for _, twig := range twigs {
+					utterances := []string{}
+					for _, utterance := range twig.section.Utterance {
+						utterances = append(utterances, utterance.Text)
+					}
+					reviews = append(reviews, Review{
+						Word:            twig.word.Text,
+						Text:            text,
+						Gloss:           twig.word.Gloss,
+						Lang:            parser.Language(lang).Code(),
+						Date:            twig.section.Date.Time,
+						Grade:           twig.grade,
+						TwoWay:          twig.word.TwoWay,
+						ProductionGrade: twig.productionGrade,
+						Reviewed:        twig.word.Parent.Reviewed,
+						Uri:             twig.location,
+						Line:            twig.word.Line,
+						Utterances:      utterances,
+					})
+				}
+			}
+		}
+	}
+
+	slices.SortFunc(reviews, func(a, b Review) int {
+		return cmp.Or(
+			a.Date.Compare(b.Date),
+			cmp.Compare(a.Uri, b.Uri),
+			cmp.Compare(a.Line, b.Line),
+			cmp.Compare(a.Word, b.Word),
+		)
+	})
+	return reviews
+}
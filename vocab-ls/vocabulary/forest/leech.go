@@ -0,0 +1,49 @@
+package forest
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	lsproto "vocab/lsp"
+)
+
+// Code of the diagnostics flagging leeches.
+const LeechCode = "leech"
+
+func leechMessage(fruit *WordFruit) string {
+	return fmt.Sprintf("Leech: forgotten %d times, try rewording it or adding an example", fruit.Lapses)
+}
+
+// Every leech of the snapshot, the most lapsed first.
+func (c *Snapshot) Leeches() []lsproto.Leech {
+	leeches := []lsproto.Leech{}
+	for key, entry := range c.words {
+		fruit := entry.Fruit(key)
+		if !c.config.IsLeech(fruit.Lapses) {
+			continue
+		}
+
+		// in the order they were written
+		locations := []lsproto.Location{}
+		for _, word := range fruit.Words {
+			locations = append(locations, wordLocation(word))
+		}
+
+		leeches = append(leeches, lsproto.Leech{
+			Word:           fruit.Text,
+			Lang:           key.lang.Code(),
+			Lapses:         fruit.Lapses,
+			EasinessFactor: fruit.EasinessFactor,
+			Locations:      locations,
+		})
+	}
+
+	slices.SortFunc(leeches, func(a, b lsproto.Leech) int {
+		return cmp.Or(
+			cmp.Compare(b.Lapses, a.Lapses),
+			cmp.Compare(a.Lang, b.Lang),
+			cmp.Compare(a.Word, b.Word),
+		)
+	})
+	return leeches
+}
@@ -0,0 +1,54 @@
+package forest
+
+import (
+	"strings"
+	"testing"
+	"time"
+	"vocab/config"
+	"vocab/lib"
+	test "vocab/vocab_testing"
+)
+
+func TestLeeches_ShouldFlagWordsThatLapsedTooOften(t *testing.T) {
+	cfg := config.Default()
+	cfg.Diagnostics.LeechThreshold = 2
+	forest := NewForest(t.Context(), func(any) {}).
+		WithClock(lib.FixedClock{Time: time.Date(2025, time.June, 20, 12, 0, 0, 0, time.Local)}).
+		Configure(cfg)
+	forest.Plant("a", test.TrimLines(`
+		01/06/2025
+		> (it) casa(1), gatto(5)
+		02/06/2025
+		>> (it) casa(2), gatto(2)
+		03/06/2025
+		>> (it) casa(1), gatto(5)
+	`), nil)
+
+	// the first failure of casa is learning it
+	leeches := forest.Leeches()
+	test.Expect(t, 1, len(leeches))
+	test.Expect(t, "casa", leeches[0].Word)
+	test.Expect(t, "it", leeches[0].Lang)
+	test.Expect(t, 2, leeches[0].Lapses)
+	test.Expect(t, 3, len(leeches[0].Locations))
+	test.Expect(t, 1, leeches[0].Locations[0].Range.Start.Line)
+
+	leechWarnings := 0
+	for _, harvested := range forest.Harvest()["a"] {
+		if harvested.Word == "casa" {
+			test.Expect(t, true, harvested.Leech)
+		}
+		if harvested.Diagnostic.Code == LeechCode {
+			leechWarnings++
+		}
+	}
+	test.Expect(t, 3, leechWarnings)
+
+	description, found := forest.Pick("a", 1, 7)
+	test.Expect(t, true, found)
+	test.Expect(t, true, strings.Contains(description, "Leech"))
+
+	cfg.Diagnostics.LeechThreshold = 0
+	forest.Configure(cfg)
+	test.Expect(t, 0, len(forest.Leeches()))
+}
@@ -0,0 +1,80 @@
+package forest
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"unicode/utf8"
+	lsproto "vocab/lsp"
+	"vocab/vocabulary/parser"
+)
+
+// Code of the diagnostics flagging new words written in an inflected form.
+const LemmaCode = "lemma"
+
+// A new word written in an inflected form, such as entrambe instead of entrambi.
+type Inflection struct {
+	Word *parser.Word
+	// The word without its article, what a fix replaces
+	Range lsproto.Range
+	// Citation forms the word could be written in instead, most likely first
+	Lemmas []string
+}
+
+// Every word of a `>` line of documentUri that the lemma list of its language knows as an
+// inflected form, in the order of the document. Words taken literally and phrases are left as they
+// are.
+func (c *Snapshot) Inflections(documentUri string) []Inflection {
+	plot, exists := c.plots[documentUri]
+	if !exists || len(c.lemmas) == 0 {
+		return nil
+	}
+
+	inflections := []Inflection{}
+	for lang, branch := range plot.Tree.branches {
+		lemmas := c.lemmas[parser.Language(lang)]
+		if lemmas == nil {
+			continue
+		}
+		for text, twigs := range branch.twigs {
+			for _, twig := range twigs {
+				if twig.word.Literally || twig.word.Parent.Reviewed || isPhrase(parser.Language(lang), twig.word) {
+					continue
+				}
+				citations := lemmas.Of(text)
+				if len(citations) == 0 {
+					continue
+				}
+				word := twig.word
+				inflections = append(inflections, Inflection{
+					Word: word,
+					Range: lsproto.Range{
+						Start: lsproto.Position{Line: word.Line, Character: word.End - utf8.RuneCountInString(text)},
+						End:   lsproto.Position{Line: word.Line, Character: word.End},
+					},
+					Lemmas: citations,
+				})
+			}
+		}
+	}
+	slices.SortFunc(inflections, func(a, b Inflection) int {
+		if a.Word.Line != b.Word.Line {
+			return a.Word.Line - b.Word.Line
+		}
+		return a.Word.Start - b.Word.Start
+	})
+	return inflections
+}
+
+func inflectionDiagnostic(inflection Inflection) *lsproto.Diagnostic {
+	word := inflection.Word
+	warning := lsproto.MakeDiagnostics(
+		fmt.Sprintf("%s is an inflected form, write %s instead", word.Text, strings.Join(inflection.Lemmas, " or ")),
+		word.Line,
+		word.Start,
+		word.End,
+		lsproto.DiagnosticsSeverityWarning,
+	)
+	warning.Code = LemmaCode
+	return warning
+}
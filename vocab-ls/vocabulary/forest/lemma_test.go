@@ -0,0 +1,48 @@
+package forest
+
+import (
+	"testing"
+	"time"
+	"vocab/dictionary"
+	"vocab/lib"
+	test "vocab/vocab_testing"
+	"vocab/vocabulary/parser"
+)
+
+func TestInflections_ShouldFlagNewWordsNotInTheirCitationForm(t *testing.T) {
+	forest := NewForest(t.Context(), func(any) {}).
+		WithClock(lib.FixedClock{Time: time.Date(2025, time.September, 18, 12, 0, 0, 0, time.Local)})
+	forest.Plant("a", test.TrimLines(`
+		18/09/2025
+		> (it) entrambe, le case, `+"`entrambe`"+`, entrambi
+		>> (it) entrambe
+		Devi fare entrambe le cose.
+	`), nil)
+	forest.Harvest()
+	test.Expect(t, 0, len(forest.Snapshot().Inflections("a")))
+
+	forest.WithLemmas(map[parser.Language]*dictionary.Lemmas{
+		parser.Italiano: dictionary.NewLemmas([]dictionary.Entry{
+			{Word: "entrambi", Definition: "entrambe"},
+			{Word: "casa", Definition: "case"},
+		}),
+	})
+
+	inflections := forest.Snapshot().Inflections("a")
+	test.Expect(t, 2, len(inflections))
+	test.Expect(t, "entrambe", inflections[0].Word.Text)
+	test.Expect(t, "entrambi", inflections[0].Lemmas[0])
+	test.Expect(t, "le case", inflections[1].Word.Text)
+	// the article stays
+	test.Expect(t, 20, inflections[1].Range.Start.Character)
+	test.Expect(t, 24, inflections[1].Range.End.Character)
+
+	warnings := 0
+	for _, harvested := range forest.Harvest()["a"] {
+		if harvested.Diagnostic.Code == LemmaCode {
+			warnings++
+			test.Expect(t, 1, harvested.Diagnostic.Range.Start.Line)
+		}
+	}
+	test.Expect(t, 2, warnings)
+}
@@ -0,0 +1,178 @@
+package forest
+
+import (
+	"slices"
+	"strings"
+	"unicode/utf8"
+	"vocab/dictionary"
+	lsproto "vocab/lsp"
+	"vocab/vocabulary/languages"
+	"vocab/vocabulary/parser"
+)
+
+// Most words between two parts of a phrase used in order, not counting articles and reflexive
+// pronouns.
+const phraseGap = 3
+
+// A phrase of a document, and where the utterances of its section use it.
+type Phrase struct {
+	Word *parser.Word
+	// One range for every word of the utterances that is a part of the phrase
+	Matches []lsproto.Range
+}
+
+// Whether word is made of several words once its article is stripped, such as fare finta di or
+// sich Sorgen machen. Words taken literally are never phrases.
+func isPhrase(lang parser.Language, word *parser.Word) bool {
+	return !word.Literally &&
+		len(strings.Fields(stripArticle(lang, word.Text))) > 1 &&
+		len(phraseParts(lang, word.Text)) > 0
+}
+
+func phraseTokens(lang parser.Language, text string) []languages.Token {
+	switch lang {
+	case parser.Italiano:
+		return languages.ItalianPhrase(text)
+	case parser.Français:
+		return languages.FrenchPhrase(text)
+	default:
+		return languages.GermanPhrase(text)
+	}
+}
+
+// The words a phrase is normalized to and matched by.
+func phraseParts(lang parser.Language, text string) []string {
+	parts := []string{}
+	for _, token := range phraseTokens(lang, text) {
+		parts = append(parts, token.Text)
+	}
+	return parts
+}
+
+// Every phrase of documentUri, in the order of the document.
+func (c *Snapshot) Phrases(documentUri string) []Phrase {
+	plot, exists := c.plots[documentUri]
+	if !exists {
+		return nil
+	}
+
+	phrases := []Phrase{}
+	for lang, branch := range plot.Tree.branches {
+		language := parser.Language(lang)
+		for text, twigs := range branch.twigs {
+			for _, twig := range twigs {
+				if !isPhrase(language, twig.word) {
+					continue
+				}
+				phrase := Phrase{Word: twig.word, Matches: []lsproto.Range{}}
+				for _, utterance := range twig.section.Utterance {
+					for _, token := range c.matchPhrase(language, strings.Fields(text), phraseTokens(language, utterance.Text)) {
+						phrase.Matches = append(phrase.Matches, lsproto.Range{
+							Start: lsproto.Position{Line: utterance.Line, Character: utterance.Start + token.Start},
+							End:   lsproto.Position{Line: utterance.Line, Character: utterance.Start + token.End},
+						})
+					}
+				}
+				phrases = append(phrases, phrase)
+			}
+		}
+	}
+	slices.SortFunc(phrases, func(a, b Phrase) int {
+		if a.Word.Line != b.Word.Line {
+			return a.Word.Line - b.Word.Line
+		}
+		return a.Word.Start - b.Word.Start
+	})
+	return phrases
+}
+
+// The tokens of an utterance that make up the phrase of parts.
+//
+// Parts come in order in Italian and French, with a few words in between at most. German moves
+// verbs around, and the prefix of a separable verb to the end of the clause, so any order will do
+// there.
+func (c *Snapshot) matchPhrase(lang parser.Language, parts []string, tokens []languages.Token) []languages.Token {
+	same := c.samePart(lang)
+	if lang == parser.Deutsch {
+		return matchAnyOrder(parts, tokens, same)
+	}
+	return matchInOrder(parts, tokens, same)
+}
+
+// Whether a token of an utterance is a form of a part of a phrase, by the lemma list of lang if
+// there is one.
+func (c *Snapshot) samePart(lang parser.Language) func(part string, token string) bool {
+	lemmas := c.lemmas[lang]
+	return func(part string, token string) bool {
+		if part == token {
+			return true
+		}
+		if lemmas != nil && slices.ContainsFunc(lemmas.Of(token), func(lemma string) bool {
+			return dictionary.Key(lemma) == part
+		}) {
+			return true
+		}
+		// regular German verbs match without a lemma list: machen, mache, macht
+		if lang == parser.Deutsch && strings.HasSuffix(part, "n") {
+			stem := strings.TrimSuffix(strings.TrimSuffix(part, "n"), "e")
+			length := utf8.RuneCountInString(stem)
+			return length >= 3 && strings.HasPrefix(token, stem) && utf8.RuneCountInString(token) <= length+3
+		}
+		return false
+	}
+}
+
+func matchInOrder(parts []string, tokens []languages.Token, same func(string, string) bool) []languages.Token {
+	matched := []languages.Token{}
+	for start := 0; start < len(tokens); start++ {
+		if !same(parts[0], tokens[start].Text) {
+			continue
+		}
+		found := []languages.Token{tokens[start]}
+		at := start
+		for _, part := range parts[1:] {
+			next := slices.IndexFunc(tokens[at+1:min(len(tokens), at+2+phraseGap)], func(token languages.Token) bool {
+				return same(part, token.Text)
+			})
+			if next < 0 {
+				break
+			}
+			at += next + 1
+			found = append(found, tokens[at])
+		}
+		if len(found) == len(parts) {
+			matched = append(matched, found...)
+			start = at
+		}
+	}
+	return matched
+}
+
+func matchAnyOrder(parts []string, tokens []languages.Token, same func(string, string) bool) []languages.Token {
+	used := make([]bool, len(tokens))
+	matched := []languages.Token{}
+	take := func(part string) bool {
+		for i, token := range tokens {
+			if !used[i] && same(part, token.Text) {
+				used[i] = true
+				matched = append(matched, token)
+				return true
+			}
+		}
+		return false
+	}
+
+	for _, part := range parts {
+		if take(part) {
+			continue
+		}
+		prefix, stem, separable := languages.SplitGermanSeparableVerb(part)
+		if !separable || !take(stem) || !take(prefix) {
+			return nil
+		}
+	}
+	slices.SortFunc(matched, func(a, b languages.Token) int {
+		return a.Start - b.Start
+	})
+	return matched
+}
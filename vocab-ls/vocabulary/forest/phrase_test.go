@@ -0,0 +1,81 @@
+package forest
+
+import (
+	"testing"
+	"time"
+	"vocab/lib"
+	test "vocab/vocab_testing"
+	"vocab/vocabulary/parser"
+)
+
+func TestGetNormalizedText_ShouldNormalizePhrasesWordByWord(t *testing.T) {
+	tree := NewWordTree()
+	normalize := func(lang parser.Language, text string) string {
+		return tree.GetNormalizedText(lang, &parser.Word{Text: text})
+	}
+
+	test.Expect(t, "sorgen machen", normalize(parser.Deutsch, "sich Sorgen machen"), normalize(parser.Deutsch, "Sorgen machen"))
+	test.Expect(t, "fare finta di", normalize(parser.Italiano, "Fare finta di"))
+	test.Expect(t, "inquiéter de", normalize(parser.Français, "s'inquiéter de"))
+	// single words keep their article stripped, and their case in German
+	test.Expect(t, "Berg", normalize(parser.Deutsch, "der Berg"))
+	test.Expect(t, "fare finta di", tree.GetNormalizedText(parser.Italiano, &parser.Word{Text: "fare finta di", Literally: true}))
+}
+
+func TestPhrases_ShouldBeMatchedInTheUtterancesOfTheirSection(t *testing.T) {
+	forest := NewForest(t.Context(), func(any) {}).
+		WithClock(lib.FixedClock{Time: time.Date(2025, time.September, 18, 12, 0, 0, 0, time.Local)})
+	forest.Plant("a", test.TrimLines(`
+		18/09/2025
+		> (it) fare finta di, la casa
+		Ha fatto finta di niente, poi fare sempre finta di dormire.
+		19/09/2025
+		> (de) sich Sorgen machen, aufhören
+		Ich mache mir keine Sorgen, hör endlich auf!
+	`), nil)
+	forest.Harvest()
+
+	phrases := forest.Snapshot().Phrases("a")
+	test.Expect(t, 2, len(phrases))
+
+	it := phrases[0]
+	test.Expect(t, "fare finta di", it.Word.Text)
+	// fatto is not fare without a lemma list, the second use has a word in between
+	test.Expect(t, 3, len(it.Matches))
+	test.Expect(t, 2, it.Matches[0].Start.Line)
+	test.Expect(t, 30, it.Matches[0].Start.Character)
+	test.Expect(t, 34, it.Matches[0].End.Character)
+
+	de := phrases[1]
+	test.Expect(t, "sich Sorgen machen", de.Word.Text)
+	test.Expect(t, 2, len(de.Matches))
+	test.Expect(t, 4, de.Matches[0].Start.Character)
+	test.Expect(t, 20, de.Matches[1].Start.Character)
+}
+
+func TestMatchPhrase_ShouldFindSeparatedPrefixes(t *testing.T) {
+	forest := NewForest(t.Context(), func(any) {})
+	forest.Plant("a", test.TrimLines(`
+		19/09/2025
+		> (de) mit etwas aufhören
+		Sie hört mit dem Rauchen auf.
+	`), nil)
+	forest.Harvest()
+
+	phrases := forest.Snapshot().Phrases("a")
+	test.Expect(t, 1, len(phrases))
+	// mit, hört and auf, etwas is nowhere
+	test.Expect(t, 0, len(phrases[0].Matches))
+
+	forest.Plant("a", test.TrimLines(`
+		19/09/2025
+		> (de) mit dem Rauchen aufhören
+		Sie hört mit dem Rauchen auf.
+	`), nil)
+	forest.Harvest()
+
+	matches := forest.Snapshot().Phrases("a")[0].Matches
+	test.Expect(t, 4, len(matches))
+	test.Expect(t, 4, matches[0].Start.Character)
+	test.Expect(t, 25, matches[3].Start.Character)
+}
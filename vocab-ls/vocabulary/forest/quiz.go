@@ -0,0 +1,59 @@
+package forest
+
+import (
+	"time"
+	lsproto "vocab/lsp"
+	"vocab/vocabulary/parser"
+)
+
+// A word due for review in one direction, and what it takes to write its grade down.
+type Card struct {
+	Lang parser.Language
+	// The normalized text
+	Word string
+	// The word as it was last written, article and backticks included, to write it again
+	Written string
+	// Uri of the document the word was last written in
+	Uri string
+	// Graded in both directions, so a grade has to tell which one it is for
+	TwoWay bool
+	// Due for producing the word from its meaning rather than recognizing it
+	Production bool
+	Leech      bool
+	Glosses    []string
+	// The latest utterance that uses the word, blanked out, nil if there is none
+	Cloze *lsproto.Cloze
+}
+
+// A card for every direction of every word due on day, the most overdue first.
+func (c *Snapshot) Cards(day time.Time) []Card {
+	cards := []Card{}
+	for _, due := range c.dueFruits(day) {
+		fruit := due.fruit
+		last := fruit.Words[len(fruit.Words)-1]
+		card := Card{
+			Lang:    fruit.Lang,
+			Word:    fruit.Text,
+			Written: last.Text,
+			Uri:     last.Uri(),
+			TwoWay:  fruit.Production != nil,
+			Leech:   c.config.IsLeech(fruit.Lapses),
+			Glosses: fruit.Glosses,
+		}
+		if last.Literally {
+			card.Written = "`" + last.Text + "`"
+		}
+		if cloze, found := c.cloze(fruit); found {
+			card.Cloze = &cloze
+		}
+
+		if due.recognition {
+			cards = append(cards, card)
+		}
+		if due.production {
+			card.Production = true
+			cards = append(cards, card)
+		}
+	}
+	return cards
+}
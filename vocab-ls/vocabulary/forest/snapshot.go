@@ -0,0 +1,573 @@
+package forest
+
+import (
+	"fmt"
+	"maps"
+	"math"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+	"vocab/config"
+	"vocab/dictionary"
+	"vocab/lib"
+	lsproto "vocab/lsp"
+	"vocab/super_memo"
+	"vocab/vocabulary/parser"
+)
+
+// Everything the forest knows about one document, as of one plant. Never modified once planted.
+type Plot struct {
+	Uri  string
+	Text string
+	Tree *WordTree
+	// Diagnostics from the parser
+	Diagnostics []*lsproto.Diagnostic
+	// Every section of the document, in order
+	Sections []*parser.VocabularySection
+}
+
+// The last section of the plot dated day, whatever its time of day, nil if there is none.
+func (p *Plot) SectionOn(day time.Time) *parser.VocabularySection {
+	for _, section := range slices.Backward(p.Sections) {
+		if section.Date != nil && lib.Day(section.Date.Time).Equal(lib.Day(day)) {
+			return section
+		}
+	}
+	return nil
+}
+
+// A consistent view of the whole forest. Never modified once stored, so it can be read from any
+// goroutine.
+//
+// Next to the plots, a snapshot keeps the merged words of every schedule, built incrementally from
+// the previous snapshot: replacing a document only rebuilds the words it touches, and only the
+// documents sharing those words are harvested again.
+type Snapshot struct {
+	// Increases with every change of the forest
+	Version uint64
+	// Map of document uri and its latest plot
+	plots  map[string]*Plot
+	config config.Config
+	clock  lib.Clock
+	// Set of folder uris whose documents are harvested apart from the rest of the forest
+	independentFolders map[string]struct{}
+	// Map of language and the lemma list inflected forms are told with
+	lemmas map[parser.Language]*dictionary.Lemmas
+
+	// Every word of every schedule, merged across documents
+	words map[wordKey]*wordEntry
+	// Map of document uri and the words it has twigs in
+	contributions map[string][]wordKey
+
+	// Harvest of this snapshot, once computed
+	harvested atomic.Pointer[harvest]
+	// Latest harvest of an earlier snapshot, nil if none can be reused
+	base *harvest
+	// Documents whose diagnostics may differ from base
+	dirty map[string]struct{}
+}
+
+// Diagnostics of every document as of one moment: the start of a day, or the current minute.
+type harvest struct {
+	at          time.Time
+	diagnostics map[string][]HarvestedDiagnostic
+}
+
+// A word of one schedule.
+type wordKey struct {
+	// Independent folder uri, or "" for the shared schedule
+	scope string
+	lang  parser.Language
+	// The normalized text
+	text string
+}
+
+// Every twig of one word across the documents of a schedule. Never modified once built.
+type wordEntry struct {
+	// Sorted by date
+	twigs []*WordTwig
+	// Documents with at least one twig
+	uris       []string
+	parameters super_memo.Parameters
+	// Every word of its language is scheduled in both directions
+	twoWay bool
+	// Fruits only depend on the twigs and the scheduler, so they are computed once per entry
+	once  sync.Once
+	fruit *WordFruit
+}
+
+func newWordEntry(key wordKey, twigs []*WordTwig, parameters super_memo.Parameters, twoWay bool) *wordEntry {
+	// the same section can only count once
+	uniques := make(map[string]*WordTwig)
+	for _, twig := range twigs {
+		uniques[twig.section.Identity()] = twig
+	}
+	uniqued := slices.SortedFunc(maps.Values(uniques), func(a, b *WordTwig) int {
+		if byDate := a.section.Date.Time.Compare(b.section.Date.Time); byDate != 0 {
+			return byDate
+		}
+		return strings.Compare(a.section.Identity(), b.section.Identity())
+	})
+
+	uris := []string{}
+	for _, twig := range uniqued {
+		if !slices.Contains(uris, twig.location) {
+			uris = append(uris, twig.location)
+		}
+	}
+
+	return &wordEntry{twigs: uniqued, uris: uris, parameters: parameters, twoWay: twoWay}
+}
+
+func (e *wordEntry) Fruit(key wordKey) *WordFruit {
+	e.once.Do(func() {
+		e.fruit = twigsToWordFruits(string(key.lang), key.text, e.twigs, e.parameters, e.twoWay)
+	})
+	return e.fruit
+}
+
+func newSnapshot(cfg config.Config, clock lib.Clock) *Snapshot {
+	return &Snapshot{
+		plots:              make(map[string]*Plot),
+		config:             cfg,
+		clock:              clock,
+		independentFolders: make(map[string]struct{}),
+		words:              make(map[wordKey]*wordEntry),
+		contributions:      make(map[string][]wordKey),
+		dirty:              make(map[string]struct{}),
+	}
+}
+
+// A copy of c to apply the next change to, before it is stored.
+func (c *Snapshot) next() *Snapshot {
+	next := &Snapshot{
+		Version:            c.Version + 1,
+		plots:              maps.Clone(c.plots),
+		config:             c.config,
+		clock:              c.clock,
+		independentFolders: maps.Clone(c.independentFolders),
+		lemmas:             c.lemmas,
+		words:              maps.Clone(c.words),
+		contributions:      maps.Clone(c.contributions),
+	}
+	if harvested := c.harvested.Load(); harvested != nil {
+		next.base = harvested
+		next.dirty = make(map[string]struct{})
+	} else {
+		next.base = c.base
+		next.dirty = maps.Clone(c.dirty)
+	}
+	return next
+}
+
+// Replace the plot of documentUri, or remove it if plot is nil, and rebuild the words it touches.
+func (c *Snapshot) replant(documentUri string, plot *Plot) {
+	scope := c.scopeOf(documentUri)
+	planted := make(map[wordKey][]*WordTwig)
+	if plot != nil {
+		for lang, branch := range plot.Tree.branches {
+			for text, twigs := range branch.twigs {
+				planted[wordKey{scope, parser.Language(lang), text}] = twigs
+			}
+		}
+	}
+
+	touched := slices.Collect(maps.Keys(planted))
+	for _, key := range c.contributions[documentUri] {
+		if _, exists := planted[key]; !exists {
+			touched = append(touched, key)
+		}
+	}
+
+	c.dirty[documentUri] = struct{}{}
+	for _, key := range touched {
+		twigs := []*WordTwig{}
+		if existing := c.words[key]; existing != nil {
+			for _, twig := range existing.twigs {
+				if twig.location != documentUri {
+					twigs = append(twigs, twig)
+				}
+			}
+			// a word's schedule is shared, every document with it needs a new harvest
+			for _, uri := range existing.uris {
+				c.dirty[uri] = struct{}{}
+			}
+		}
+		twigs = append(twigs, planted[key]...)
+
+		if len(twigs) == 0 {
+			delete(c.words, key)
+			continue
+		}
+		c.words[key] = newWordEntry(key, twigs, c.config.Parameters(), c.config.IsTwoWay(key.lang))
+	}
+
+	if plot == nil {
+		delete(c.plots, documentUri)
+		delete(c.contributions, documentUri)
+		return
+	}
+	c.plots[documentUri] = plot
+	c.contributions[documentUri] = slices.Collect(maps.Keys(planted))
+}
+
+// Rebuild every word from scratch, for changes that affect all of them such as the scheduler or
+// the independent folders.
+func (c *Snapshot) regrow() {
+	c.words = make(map[wordKey]*wordEntry)
+	c.contributions = make(map[string][]wordKey)
+	c.base = nil
+	c.dirty = make(map[string]struct{})
+
+	twigs := make(map[wordKey][]*WordTwig)
+	for uri, plot := range c.plots {
+		scope := c.scopeOf(uri)
+		for lang, branch := range plot.Tree.branches {
+			for text, planted := range branch.twigs {
+				key := wordKey{scope, parser.Language(lang), text}
+				twigs[key] = append(twigs[key], planted...)
+				c.contributions[uri] = append(c.contributions[uri], key)
+			}
+		}
+	}
+	for key, planted := range twigs {
+		c.words[key] = newWordEntry(key, planted, c.config.Parameters(), c.config.IsTwoWay(key.lang))
+	}
+}
+
+// The independent folder documentUri belongs to, or "" for the shared schedule.
+//
+// Nested independent folders resolve to the innermost one.
+func (c *Snapshot) scopeOf(documentUri string) string {
+	scope := ""
+	for folderUri := range c.independentFolders {
+		if isInFolder(documentUri, folderUri) && len(folderUri) > len(scope) {
+			scope = folderUri
+		}
+	}
+	return scope
+}
+
+func isInFolder(documentUri string, folderUri string) bool {
+	return strings.HasPrefix(documentUri, strings.TrimSuffix(folderUri, "/")+"/")
+}
+
+type HarvestedDiagnostic struct {
+	Diagnostic lsproto.Diagnostic
+	Word       string
+	Lang       parser.Language
+	// The word lapsed often enough to be a leech
+	Leech bool
+	// Due for producing the word from its meaning rather than recognizing it
+	Production bool
+}
+
+// Code of the due diagnostics of producing a word graded in both directions.
+const ProductionCode = "production"
+
+// Based on the merged words, compile them into diagnostics as of now.
+//
+// Diagnostics only change with the day, or with the minute while learning steps count down, so
+// documents that did not change since the last harvest of the same day or minute are reused as is.
+func (c *Snapshot) Harvest() map[string][]HarvestedDiagnostic {
+	return c.harvestAt(c.harvestMoment())
+}
+
+// The start of the day, or of the minute if learning steps are configured.
+func (c *Snapshot) harvestMoment() time.Time {
+	if len(c.config.Parameters().LearningSteps) == 0 {
+		return c.Today()
+	}
+	return c.Now().Truncate(time.Minute)
+}
+
+// Whether a word, recognized or produced, is going through its learning steps. Its diagnostic then
+// counts down by the minute.
+func (c *Snapshot) Learning() bool {
+	if len(c.config.Parameters().LearningSteps) == 0 {
+		return false
+	}
+	for key, entry := range c.words {
+		fruit := entry.Fruit(key)
+		if fruit.Learning || (fruit.Production != nil && fruit.Production.Learning) {
+			return true
+		}
+	}
+	return false
+}
+
+// Compile diagnostics as they will be, or were, on day.
+func (c *Snapshot) HarvestOn(day time.Time) map[string][]HarvestedDiagnostic {
+	if lib.Day(day).Equal(c.Today()) {
+		return c.Harvest()
+	}
+	return c.harvestAt(lib.Day(day))
+}
+
+func (c *Snapshot) harvestAt(at time.Time) map[string][]HarvestedDiagnostic {
+	if harvested := c.harvested.Load(); harvested != nil && harvested.at.Equal(at) {
+		return maps.Clone(harvested.diagnostics)
+	}
+
+	var diags map[string][]HarvestedDiagnostic
+	var dirty []string
+	if c.base != nil && c.base.at.Equal(at) {
+		diags = maps.Clone(c.base.diagnostics)
+		dirty = slices.Collect(maps.Keys(c.dirty))
+	} else {
+		diags = make(map[string][]HarvestedDiagnostic, len(c.plots))
+		dirty = slices.Collect(maps.Keys(c.plots))
+	}
+
+	for _, uri := range dirty {
+		if _, exists := c.plots[uri]; !exists {
+			delete(diags, uri)
+			continue
+		}
+		diags[uri] = c.harvestDocument(uri, at)
+	}
+
+	// any other day is a one-off, keep the current harvest for the next snapshot to build on
+	if at.Equal(c.harvestMoment()) {
+		c.harvested.Store(&harvest{at: at, diagnostics: diags})
+	}
+	return maps.Clone(diags)
+}
+
+func (c *Snapshot) harvestDocument(documentUri string, at time.Time) []HarvestedDiagnostic {
+	diags := []HarvestedDiagnostic{}
+
+	for _, key := range c.contributions[documentUri] {
+		fruit := c.words[key].Fruit(key)
+		for _, warning := range genderDiagnostics(fruit, documentUri) {
+			diags = append(diags, HarvestedDiagnostic{
+				Lang:       fruit.Lang,
+				Diagnostic: *warning,
+				Word:       fruit.Text,
+			})
+		}
+
+		message, severity := c.due(fruit, at)
+		production, productionSeverity := "", severity
+		if fruit.Production != nil {
+			production, productionSeverity = c.due(fruit.Production, at)
+			if message != "" {
+				message = "Recognition: " + message
+			}
+			if production != "" {
+				production = "Production: " + production
+			}
+		}
+		leech := c.config.IsLeech(fruit.Lapses)
+		if message == "" && production == "" && !leech {
+			continue
+		}
+
+		for _, word := range fruit.Words {
+			if word.Uri() != documentUri {
+				continue
+			}
+			if message != "" {
+				err := lsproto.MakeDiagnostics(
+					message,
+					word.Line,
+					word.Start,
+					word.End,
+					severity,
+				)
+				diags = append(diags, HarvestedDiagnostic{
+					Lang:       fruit.Lang,
+					Diagnostic: *err,
+					Word:       fruit.Text,
+					Leech:      leech,
+				})
+			}
+			if production != "" {
+				err := lsproto.MakeDiagnostics(
+					production,
+					word.Line,
+					word.Start,
+					word.End,
+					productionSeverity,
+				)
+				err.Code = ProductionCode
+				diags = append(diags, HarvestedDiagnostic{
+					Lang:       fruit.Lang,
+					Diagnostic: *err,
+					Word:       fruit.Text,
+					Leech:      leech,
+					Production: true,
+				})
+			}
+			if leech {
+				warning := lsproto.MakeDiagnostics(
+					leechMessage(fruit),
+					word.Line,
+					word.Start,
+					word.End,
+					lsproto.DiagnosticsSeverityWarning,
+				)
+				warning.Code = LeechCode
+				diags = append(diags, HarvestedDiagnostic{
+					Lang:       fruit.Lang,
+					Diagnostic: *warning,
+					Word:       fruit.Text,
+					Leech:      true,
+				})
+			}
+		}
+	}
+
+	for _, inflection := range c.Inflections(documentUri) {
+		diags = append(diags, HarvestedDiagnostic{
+			Diagnostic: *inflectionDiagnostic(inflection),
+			Word:       "",
+		})
+	}
+
+	for _, diag := range c.plots[documentUri].Diagnostics {
+		diags = append(diags, HarvestedDiagnostic{
+			Diagnostic: *diag,
+			Word:       "",
+		})
+	}
+
+	// words come out of maps, keep the output stable for the client
+	slices.SortStableFunc(diags, func(a, b HarvestedDiagnostic) int {
+		if a.Diagnostic.Range.Start.Line != b.Diagnostic.Range.Start.Line {
+			return a.Diagnostic.Range.Start.Line - b.Diagnostic.Range.Start.Line
+		}
+		return a.Diagnostic.Range.Start.Character - b.Diagnostic.Range.Start.Character
+	})
+
+	return diags
+}
+
+// What the diagnostic of a schedule says as of at, and how loud it is. Nothing is said of words
+// due later, other than words going through learning steps.
+func (c *Snapshot) due(fruit *WordFruit, at time.Time) (string, lsproto.DiagnosticsSeverity) {
+	timeRemaining := fruitToRemainingDays(fruit, lib.Day(at))
+	if fruit.Learning {
+		timeRemaining = fruit.Due.Sub(at).Hours() / 24
+	}
+
+	severity := lsproto.DiagnosticsSeverityInformation
+	if timeRemaining <= c.config.Diagnostics.ErrorWithinDays {
+		severity = lsproto.DiagnosticsSeverityError
+	} else if timeRemaining < c.config.Diagnostics.HintWithinDays {
+		severity = lsproto.DiagnosticsSeverityHint
+	}
+	if fruit.Learning && timeRemaining > 0 {
+		// not yet, but soon enough to keep in mind
+		severity = lsproto.DiagnosticsSeverityHint
+	}
+
+	if fruit.Learning {
+		return learningMessage(fruit.Due.Sub(at)), severity
+	}
+	if timeRemaining == 0 {
+		return "Review now!", severity
+	}
+	// can keep this for hover action
+	if timeRemaining > 0 {
+		return "", severity
+	}
+	return fmt.Sprintf("%d days past deadline", int(math.Ceil(timeRemaining*-1))), severity
+}
+
+// The latest plot of documentUri, if it is planted.
+func (c *Snapshot) Plot(documentUri string) (*Plot, bool) {
+	plot, exists := c.plots[documentUri]
+	return plot, exists
+}
+
+// Uris of every planted document.
+func (f *Snapshot) Uris() []string {
+	return slices.Collect(maps.Keys(f.plots))
+}
+
+// Pick a fruit based on its location in the tree and return its remaining days description
+func (f *Snapshot) Pick(textDocument string, line int, character int) (string, bool) {
+	picked, found := f.PickFruit(textDocument, line, character)
+	if !found {
+		return "", false
+	}
+	description := f.remaining(picked)
+	if picked.Production != nil {
+		description = "Recognition: " + description + "\nProduction: " + f.remaining(picked.Production)
+	}
+	if f.config.IsLeech(picked.Lapses) {
+		description += "\n" + leechMessage(picked)
+	}
+	if gender := genderMessage(picked); gender != "" {
+		description = gender + "\n" + description
+	}
+	if len(picked.Glosses) > 0 {
+		description = strings.Join(picked.Glosses, "; ") + "\n" + description
+	}
+	return description, true
+}
+
+// How long until a schedule is due, in days, or hours while it is learning.
+func (f *Snapshot) remaining(fruit *WordFruit) string {
+	if fruit.Learning {
+		return fmt.Sprintf("Remaining hours: %.1f", fruit.Due.Sub(f.Now()).Hours())
+	}
+	return fmt.Sprintf("Remaining days: %f", fruitToRemainingDays(fruit, f.Today()))
+}
+
+// The fruit of the word at a location, merged across every document of its schedule.
+func (f *Snapshot) PickFruit(textDocument string, line int, character int) (*WordFruit, bool) {
+	plot, exists := f.plots[textDocument]
+	if !exists {
+		return nil, false
+	}
+	picked := plot.Tree.Pick(line, character)
+	if picked == nil {
+		return nil, false
+	}
+	// the document alone doesn't know when the word was last reviewed elsewhere
+	key := wordKey{f.scopeOf(textDocument), picked.Lang, picked.Text}
+	if entry, exists := f.words[key]; exists {
+		picked = entry.Fruit(key)
+	}
+	return picked, true
+}
+
+// The current day according to the clock of the forest.
+func (c *Snapshot) Today() time.Time {
+	return lib.Day(c.clock.Now())
+}
+
+// The current time according to the clock of the forest, comparable with the times of sections.
+func (c *Snapshot) Now() time.Time {
+	return lib.Moment(c.clock.Now())
+}
+
+// Days between today and the deadline of fruit, negative if overdue. Whole days unless the fruit is
+// learning.
+func fruitToRemainingDays(fruit *WordFruit, today time.Time) float64 {
+	if fruit == nil {
+		panic("Fruit is null here...what?!")
+	}
+	remainingHours := fruit.Due.Sub(today).Hours()
+	var remainingDays float64 = remainingHours / 24
+	return remainingDays
+}
+
+// What is left of a learning step, in hours, or minutes under an hour.
+func learningMessage(remaining time.Duration) string {
+	switch {
+	case remaining > time.Hour:
+		return fmt.Sprintf("Review in %d hours", int(math.Ceil(remaining.Hours())))
+	case remaining > 0:
+		return fmt.Sprintf("Review in %d minutes", int(math.Ceil(remaining.Minutes())))
+	case remaining > -time.Hour:
+		return "Review now!"
+	case remaining > -24*time.Hour:
+		return fmt.Sprintf("%d hours past deadline", int(-remaining.Hours()))
+	}
+	return fmt.Sprintf("%d days past deadline", int(-remaining.Hours()/24))
+}
@@ -0,0 +1,111 @@
+package forest
+
+import (
+	"maps"
+	"slices"
+	"time"
+	"vocab/lib"
+	lsproto "vocab/lsp"
+	"vocab/super_memo"
+	"vocab/vocabulary/parser"
+)
+
+// Words whose interval reached this many days are mature, as in Anki.
+const MatureInterval = 21
+
+// Aggregate the learning statistics of every document as of day, with a heatmap of the last
+// heatmapDays days.
+func (c *Snapshot) Stats(day time.Time, heatmapDays int) lsproto.VocabStats {
+	day = lib.Day(day)
+	stats := lsproto.VocabStats{
+		WordsPerLanguage:  make(map[string]int),
+		NewWordsPerDay:    []lsproto.DailyCount{},
+		ReviewsPerDay:     []lsproto.DailyCount{},
+		GradeDistribution: make([]int, super_memo.MemoPerfect+1),
+		Heatmap:           []lsproto.DailyCount{},
+	}
+
+	// the same word in two independent folders is still one word
+	distinct := make(map[wordKey]struct{})
+	newWords := make(map[time.Time]int)
+	reviews := make(map[time.Time]int)
+	// new words and reviews
+	activity := make(map[time.Time]int)
+	easinessFactors := 0.0
+
+	for key, entry := range c.words {
+		fruit := entry.Fruit(key)
+		distinct[wordKey{lang: key.lang, text: key.text}] = struct{}{}
+		easinessFactors += fruit.EasinessFactor
+		if fruit.Interval >= MatureInterval {
+			stats.MatureWords++
+		} else {
+			stats.YoungWords++
+		}
+
+		for _, twig := range entry.twigs {
+			date := lib.Day(twig.section.Date.Time)
+			if date.After(day) {
+				continue
+			}
+			if twig.grade != parser.Ungraded {
+				stats.GradeDistribution[twig.grade]++
+			}
+			activity[date]++
+			if twig.word.Parent.Reviewed {
+				reviews[date]++
+			} else {
+				newWords[date]++
+			}
+		}
+	}
+
+	for key := range distinct {
+		stats.WordsPerLanguage[key.lang.Code()]++
+	}
+	stats.TotalWords = len(distinct)
+	if len(c.words) > 0 {
+		stats.AverageEasinessFactor = easinessFactors / float64(len(c.words))
+	}
+
+	stats.NewWordsPerDay = dailyCounts(newWords)
+	stats.ReviewsPerDay = dailyCounts(reviews)
+
+	// a streak is not broken before the day is over
+	streakEnd := day
+	if activity[day] == 0 {
+		streakEnd = day.AddDate(0, 0, -1)
+	}
+	for date := streakEnd; activity[date] > 0; date = date.AddDate(0, 0, -1) {
+		stats.CurrentStreak++
+	}
+
+	activeDays := slices.SortedFunc(maps.Keys(activity), time.Time.Compare)
+	streak := 0
+	for i, date := range activeDays {
+		if i > 0 && date.Equal(activeDays[i-1].AddDate(0, 0, 1)) {
+			streak++
+		} else {
+			streak = 1
+		}
+		stats.LongestStreak = max(stats.LongestStreak, streak)
+	}
+
+	for i := heatmapDays - 1; i >= 0; i-- {
+		date := day.AddDate(0, 0, -i)
+		stats.Heatmap = append(stats.Heatmap, lsproto.DailyCount{
+			Date:  date.Format(time.DateOnly),
+			Count: activity[date],
+		})
+	}
+
+	return stats
+}
+
+func dailyCounts(counts map[time.Time]int) []lsproto.DailyCount {
+	result := []lsproto.DailyCount{}
+	for _, date := range slices.SortedFunc(maps.Keys(counts), time.Time.Compare) {
+		result = append(result, lsproto.DailyCount{Date: date.Format(time.DateOnly), Count: counts[date]})
+	}
+	return result
+}
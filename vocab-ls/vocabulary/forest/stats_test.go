@@ -0,0 +1,49 @@
+package forest
+
+import (
+	"testing"
+	"time"
+	"vocab/lib"
+	test "vocab/vocab_testing"
+)
+
+func TestStats_ShouldAggregateWordsActivityAndStreaks(t *testing.T) {
+	forest := NewForest(t.Context(), func(any) {}).WithClock(lib.FixedClock{Time: time.Date(2025, time.June, 9, 12, 0, 0, 0, time.Local)})
+	forest.Plant("a", test.TrimLines(`
+		01/06/2025
+		> (it) casa(3), mostrare(5)
+		> (de) der Hund(2)
+		07/06/2025
+		>> (it) casa(4)
+		08/06/2025
+		> (it) gatto(5)
+		20/06/2025
+		> (it) cane(5)
+	`), nil)
+
+	stats := forest.Stats(forest.Snapshot().Today(), 10)
+	// cane is written after the day and its section is ignored, but the word exists
+	test.Expect(t, 5, stats.TotalWords)
+	test.Expect(t, 4, stats.WordsPerLanguage["it"])
+	test.Expect(t, 1, stats.WordsPerLanguage["de"])
+
+	test.Expect(t, 2, len(stats.NewWordsPerDay))
+	test.Expect(t, "2025-06-01", stats.NewWordsPerDay[0].Date)
+	test.Expect(t, 3, stats.NewWordsPerDay[0].Count)
+	test.Expect(t, 1, len(stats.ReviewsPerDay))
+	test.Expect(t, "2025-06-07", stats.ReviewsPerDay[0].Date)
+
+	test.Expect(t, 1, stats.GradeDistribution[2])
+	test.Expect(t, 1, stats.GradeDistribution[3])
+	test.Expect(t, 1, stats.GradeDistribution[4])
+	test.Expect(t, 2, stats.GradeDistribution[5])
+
+	// nothing yet today, so the streak runs through yesterday
+	test.Expect(t, 2, stats.CurrentStreak)
+	test.Expect(t, 2, stats.LongestStreak)
+
+	test.Expect(t, 10, len(stats.Heatmap))
+	test.Expect(t, "2025-06-09", stats.Heatmap[9].Date)
+	test.Expect(t, 1, stats.Heatmap[8].Count)
+	test.Expect(t, 0, stats.Heatmap[9].Count)
+}
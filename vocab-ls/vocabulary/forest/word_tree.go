@@ -2,9 +2,11 @@ package forest
 
 import (
 	"maps"
+	"math"
 	"slices"
 	"strings"
 	"time"
+	"vocab/lib"
 	lsproto "vocab/lsp"
 	"vocab/super_memo"
 	"vocab/vocabulary/languages"
@@ -16,13 +18,20 @@ import (
 // array of `twigs` of sections they are in
 type WordTree struct {
 	branches map[string]*LanguageBranch
+	// Scheduler used when harvesting fruits
+	parameters super_memo.Parameters
 }
 
 func NewWordTree() *WordTree {
-	tree := &WordTree{branches: map[string]*LanguageBranch{}}
+	tree := &WordTree{branches: map[string]*LanguageBranch{}, parameters: super_memo.DefaultParameters}
 	return tree
 }
 
+func (wt *WordTree) WithParameters(parameters super_memo.Parameters) *WordTree {
+	wt.parameters = parameters
+	return wt
+}
+
 func (wt *WordTree) GetTwigs(language parser.Language, word string) []*WordTwig {
 	existingBranch := wt.branches[string(language)]
 	if existingBranch == nil {
@@ -46,8 +55,18 @@ func (wt *WordTree) AddTwig(language parser.Language, word *parser.Word, uri str
 		wt.branches[lang] = branch
 	}
 
-	clamped_grade := max(super_memo.MemoBlackout, min(word.Grade, super_memo.MemoPerfect))
-	if clamped_grade != word.Grade {
+	clamp := func(grade int) int {
+		if word.TwoWay && grade == parser.Ungraded {
+			return grade
+		}
+		return max(super_memo.MemoBlackout, min(grade, super_memo.MemoPerfect))
+	}
+	clamped_grade := clamp(word.Grade)
+	production_grade := clamped_grade
+	if word.TwoWay {
+		production_grade = clamp(word.ProductionGrade)
+	}
+	if clamped_grade != word.Grade || (word.TwoWay && production_grade != word.ProductionGrade) {
 		newDiag := lsproto.MakeDiagnostics(
 			"Expect grade to be from 0 to 5. Can also leave empty for the default 0",
 			word.Line,
@@ -58,10 +77,11 @@ func (wt *WordTree) AddTwig(language parser.Language, word *parser.Word, uri str
 		startingDiagnostics = append(startingDiagnostics, newDiag)
 	}
 	twig := &WordTwig{
-		location: section.Uri,
-		word:     word,
-		grade:    clamped_grade,
-		section:  section,
+		location:        section.Uri,
+		word:            word,
+		grade:           clamped_grade,
+		productionGrade: production_grade,
+		section:         section,
 	}
 
 	norm := wt.GetNormalizedText(language, word)
@@ -73,16 +93,22 @@ func (wt *WordTree) GetNormalizedText(lang parser.Language, word *parser.Word) s
 	if word.Literally {
 		return word.Text
 	}
+	if isPhrase(lang, word) {
+		return strings.Join(phraseParts(lang, word.Text), " ")
+	}
+	return stripArticle(lang, word.Text)
+}
 
+func stripArticle(lang parser.Language, text string) string {
 	switch lang {
 	case parser.Italiano:
-		norm := languages.StripItalianArticleFromWord(strings.ToLower(word.Text))
+		norm := languages.StripItalianArticleFromWord(strings.ToLower(text))
 		return norm
 	case parser.Français:
-		norm := languages.StripFrenchArticleFromWord(strings.ToLower(word.Text))
+		norm := languages.StripFrenchArticleFromWord(strings.ToLower(text))
 		return norm
 	default:
-		norm := languages.StripGermanArticleFromWord(word.Text)
+		norm := languages.StripGermanArticleFromWord(text)
 		return norm
 	}
 }
@@ -111,7 +137,7 @@ func (wt *WordTree) Pick(line int, char int) *WordFruit {
 			if !someInRange {
 				continue
 			}
-			wordFruit := twigsToWordFruits(lang, word, twigs)
+			wordFruit := twigsToWordFruits(lang, word, twigs, wt.parameters, false)
 
 			return wordFruit
 		}
@@ -126,7 +152,7 @@ func (wt *WordTree) Harvest() []*WordFruit {
 	// für jede WordTwig auf LanguageBranch (Wir gehen davon aus, dass die Twigs schon sortiert sind.)
 	for lang, langBranch := range wt.branches {
 		for word, twigs := range langBranch.twigs {
-			wordFruit := twigsToWordFruits(lang, word, twigs)
+			wordFruit := twigsToWordFruits(lang, word, twigs, wt.parameters, false)
 			details = append(details, wordFruit)
 		}
 	}
@@ -134,7 +160,9 @@ func (wt *WordTree) Harvest() []*WordFruit {
 	return details
 }
 
-func twigsToWordFruits(lang string, word string, twigs []*WordTwig) *WordFruit {
+// The fruit of twigs, sorted by date. If the word is twoWay, or any twig is graded in both
+// directions, producing it gets a schedule of its own.
+func twigsToWordFruits(lang string, word string, twigs []*WordTwig, parameters super_memo.Parameters, twoWay bool) *WordFruit {
 	wordFruit := &WordFruit{
 		Words:        []*parser.Word{},
 		Interval:     0,
@@ -143,14 +171,47 @@ func twigsToWordFruits(lang string, word string, twigs []*WordTwig) *WordFruit {
 		Text:         word,
 	}
 
+	for _, twig := range twigs {
+		wordFruit.Words = append(wordFruit.Words, twig.word)
+		if twig.word.Gloss != "" && !slices.Contains(wordFruit.Glosses, twig.word.Gloss) {
+			wordFruit.Glosses = append(wordFruit.Glosses, twig.word.Gloss)
+		}
+		if gender := GenderOf(wordFruit.Lang, twig.word); gender != languages.Ungendered {
+			wordFruit.Gender = gender
+		}
+		twoWay = twoWay || twig.word.TwoWay
+	}
+
+	if twoWay {
+		production := *wordFruit
+		production.schedule(twigs, func(twig *WordTwig) int { return twig.productionGrade }, parameters)
+		wordFruit.Production = &production
+	}
+	wordFruit.schedule(twigs, func(twig *WordTwig) int { return twig.grade }, parameters)
+
+	return wordFruit
+}
+
+// Schedule the next review of fruit by the grades of twigs, sorted by date. Twigs Ungraded in this
+// direction don't count, unless it is the first one: a new word is new both ways.
+func (wordFruit *WordFruit) schedule(twigs []*WordTwig, gradeOf func(twig *WordTwig) int, parameters super_memo.Parameters) {
 	repetitionNumber := 0
-	easinessFactor := super_memo.InitialEasinessFactor
+	easinessFactor := parameters.InitialEasinessFactor
+	lapses := 0
+	// index of the learning step the word is at, -1 once SM-2 schedules it
+	learningStep := -1
 
 	// interval is the final output we want
 	var interval float64
 	var lastSeenDate *time.Time
 	for _, twig := range twigs {
-		wordFruit.Words = append(wordFruit.Words, twig.word)
+		grade := gradeOf(twig)
+		if grade == parser.Ungraded {
+			if lastSeenDate != nil {
+				continue
+			}
+			grade = super_memo.MemoBlackout
+		}
 		currentInterval := func() float64 {
 			if lastSeenDate == nil {
 				return 0
@@ -159,15 +220,45 @@ func twigsToWordFruits(lang string, word string, twigs []*WordTwig) *WordFruit {
 			diffDays := diff.Hours() / 24
 			return diffDays
 		}()
-		repetitionNumber, interval, easinessFactor = super_memo.Sm2(twig.grade, repetitionNumber, currentInterval, easinessFactor)
+		failed := grade < super_memo.MemoCorrectHard
+		// failing the first time a word is seen is learning it, not forgetting it
+		if lastSeenDate != nil && failed {
+			lapses++
+		}
+
+		switch {
+		case failed && len(parameters.LearningSteps) > 0:
+			// failing again while learning starts the steps over, but is the same forgetting
+			if learningStep < 0 {
+				repetitionNumber, interval, easinessFactor = parameters.Sm2(grade, repetitionNumber, currentInterval, easinessFactor)
+			}
+			learningStep = 0
+		case learningStep >= 0:
+			// passing a step only moves on to the next one, SM-2 takes over after the last
+			learningStep++
+			if learningStep == len(parameters.LearningSteps) {
+				learningStep = -1
+				repetitionNumber, interval, easinessFactor = parameters.Sm2(grade, repetitionNumber, currentInterval, easinessFactor)
+			}
+		default:
+			repetitionNumber, interval, easinessFactor = parameters.Sm2(grade, repetitionNumber, currentInterval, easinessFactor)
+		}
 
 		lastSeenDate = &twig.section.Date.Time
 	}
 
 	wordFruit.Interval = interval
 	wordFruit.LastSeenDate = *lastSeenDate
-
-	return wordFruit
+	wordFruit.RepetitionNumber = repetitionNumber
+	wordFruit.EasinessFactor = easinessFactor
+	wordFruit.Lapses = lapses
+	if learningStep >= 0 {
+		wordFruit.Learning = true
+		wordFruit.Due = lastSeenDate.Add(parameters.LearningSteps[learningStep])
+	} else {
+		// due on a day, whatever time it was last seen at
+		wordFruit.Due = lib.Day(*lastSeenDate).AddDate(0, 0, int(math.Ceil(interval)))
+	}
 }
 
 type LanguageBranch struct {
@@ -218,9 +309,12 @@ func (lb *LanguageBranch) sortTwigs(word string) {
 }
 
 type WordTwig struct {
-	grade   int
-	word    *parser.Word
-	section *parser.VocabularySection // word.Parent.Parent
+	// grade of recognizing the word, Ungraded if only producing it was graded
+	grade int
+	// grade of producing the word, the same as grade unless graded in both directions
+	productionGrade int
+	word            *parser.Word
+	section         *parser.VocabularySection // word.Parent.Parent
 	// Document location file name)
 	location string
 }
@@ -238,4 +332,20 @@ type WordFruit struct {
 	Text         string
 	Interval     float64
 	LastSeenDate time.Time
+	// SM-2 state after the last review, to schedule the next one
+	RepetitionNumber int
+	EasinessFactor   float64
+	// Reviews graded below 3, each one sent the word back to the first interval
+	Lapses int
+	// When the word is due: the start of a day, or a moment of one while Learning
+	Due time.Time
+	// Going through the learning steps after a failure, due in minutes or hours rather than days
+	Learning bool
+	// Every distinct gloss the word was written with, oldest first
+	Glosses []string
+	// The gender the word was last written with, Ungendered if never
+	Gender languages.Gender
+	// The schedule of producing the word from its meaning, if it is graded in both directions. The
+	// fruit itself is then the schedule of recognizing it.
+	Production *WordFruit
 }
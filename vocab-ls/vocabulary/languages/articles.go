@@ -51,6 +51,7 @@ var articoli = func() map[string]struct{} {
 		"le ",
 		"una ",
 		"uno ",
+		"un ",
 		"un'",
 	}
 	set := make(map[string]struct{})
@@ -66,7 +67,8 @@ func strip(set map[string]struct{}, word string, checkFor string) (string, bool)
 		return word, false
 	}
 
-	possibleArticle := splitted[0]
+	// the article may start a sentence: Der Hund
+	possibleArticle := strings.ToLower(splitted[0])
 	if _, exists := set[possibleArticle+checkFor]; exists {
 		return strings.Join(splitted[1:], checkFor), true
 	}
@@ -95,3 +97,65 @@ func StripItalianArticleFromWord(word string) string {
 	maybeStripped, _ := strip(articoli, word, "'")
 	return maybeStripped
 }
+
+// The grammatical gender of a noun, as told by its article.
+type Gender string
+
+const (
+	// No article, or one shared by several genders such as ein or l'
+	Ungendered Gender = ""
+	Masculine  Gender = "masculine"
+	Feminine   Gender = "feminine"
+	Neuter     Gender = "neuter"
+)
+
+// Articles that tell the gender of the noun after them. Plural articles tell the gender of the
+// plural, which is a word of its own.
+var geschlechter = map[string]Gender{
+	"der ":  Masculine,
+	"die ":  Feminine,
+	"das ":  Neuter,
+	"eine ": Feminine,
+}
+
+var genres = map[string]Gender{
+	"le ":  Masculine,
+	"un ":  Masculine,
+	"du ":  Masculine,
+	"la ":  Feminine,
+	"une ": Feminine,
+}
+
+var generi = map[string]Gender{
+	"il ":  Masculine,
+	"lo ":  Masculine,
+	"uno ": Masculine,
+	"un ":  Masculine,
+	"i ":   Masculine,
+	"gli ": Masculine,
+	"la ":  Feminine,
+	"una ": Feminine,
+	"un'":  Feminine,
+	"le ":  Feminine,
+}
+
+func genderOf(genders map[string]Gender, word string) Gender {
+	for article, gender := range genders {
+		if len(word) > len(article) && strings.HasPrefix(word, article) {
+			return gender
+		}
+	}
+	return Ungendered
+}
+
+func GermanGenderOfWord(word string) Gender {
+	return genderOf(geschlechter, word)
+}
+
+func FrenchGenderOfWord(word string) Gender {
+	return genderOf(genres, word)
+}
+
+func ItalianGenderOfWord(word string) Gender {
+	return genderOf(generi, word)
+}
@@ -17,6 +17,7 @@ func TestStripGermanArticleFromWord(t *testing.T) {
 		{"den Baum", "Baum"},
 		{"dem Haus", "Haus"},
 		{"des Mannes", "Mannes"},
+		{"Der Hund", "Hund"}, // at the start of a line
 		// Edge case: double spaces
 		{"der  Hund", " Hund"},
 	}
@@ -42,6 +43,7 @@ func TestStripItalianArticleFromWord(t *testing.T) {
 		{"le case", "case"},
 		{"una ragazza", "ragazza"},
 		{"uno studente", "studente"},
+		{"un libro", "libro"},
 		{"un'amica", "amica"},
 		{"l'acqua", "acqua"},
 		{"pizza", "pizza"}, // no article
@@ -56,3 +58,33 @@ func TestStripItalianArticleFromWord(t *testing.T) {
 		}
 	}
 }
+
+func TestGenderOfWord(t *testing.T) {
+	tests := []struct {
+		gender func(string) Gender
+		in     string
+		want   Gender
+	}{
+		{GermanGenderOfWord, "der Berg", Masculine},
+		{GermanGenderOfWord, "die Katze", Feminine},
+		{GermanGenderOfWord, "das Auto", Neuter},
+		{GermanGenderOfWord, "ein Mann", Ungendered}, // masculine or neuter
+		{GermanGenderOfWord, "Berg", Ungendered},
+		{FrenchGenderOfWord, "le chat", Masculine},
+		{FrenchGenderOfWord, "une maison", Feminine},
+		{FrenchGenderOfWord, "l'eau", Ungendered},
+		{ItalianGenderOfWord, "lo studente", Masculine},
+		{ItalianGenderOfWord, "un libro", Masculine},
+		{ItalianGenderOfWord, "un'amica", Feminine},
+		{ItalianGenderOfWord, "l'acqua", Ungendered},
+		// Edge case: nothing after the article
+		{ItalianGenderOfWord, "la ", Ungendered},
+	}
+
+	for _, tt := range tests {
+		got := tt.gender(tt.in)
+		if got != tt.want {
+			t.Errorf("gender of %q = %q; want %q", tt.in, got, tt.want)
+		}
+	}
+}
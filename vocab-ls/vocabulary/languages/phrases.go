@@ -0,0 +1,111 @@
+package languages
+
+import (
+	"strings"
+	"unicode"
+)
+
+// A word of a text, with its place in the text counted in runes.
+type Token struct {
+	Text  string
+	Start int
+	End   int
+}
+
+// Split text into its words. Elided articles and pronouns, as in l'acqua or s'inquiéter, are words
+// of their own.
+func Tokenize(text string) []Token {
+	tokens := []Token{}
+	runes := []rune(text)
+	start := -1
+	flush := func(end int) {
+		if start >= 0 {
+			tokens = append(tokens, Token{Text: string(runes[start:end]), Start: start, End: end})
+			start = -1
+		}
+	}
+
+	for i, r := range runes {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			if start < 0 {
+				start = i
+			}
+		case r == '-' && start >= 0:
+			// part of the word, as in porte-monnaie
+		case (r == '\'' || r == '’') && start >= 0:
+			tokens = append(tokens, Token{Text: string(runes[start:i]) + "'", Start: start, End: i + 1})
+			start = -1
+		default:
+			flush(i)
+		}
+	}
+	flush(len(runes))
+	return tokens
+}
+
+// Words that say nothing about which phrase it is: articles, and reflexive pronouns that change
+// with the person.
+var fuellwoerter = set(
+	"der", "den", "dem", "des", "die", "das", "ein", "einen", "einem", "eines", "eine", "einer",
+	"sich", "mich", "dich", "mir", "dir", "uns", "euch",
+)
+
+var mots = set(
+	"le", "la", "les", "l'", "un", "une", "des", "du",
+	"se", "s'", "me", "m'", "te", "t'", "nous", "vous",
+)
+
+var riempitivi = set(
+	"il", "lo", "la", "l'", "i", "gli", "le", "un", "uno", "una", "un'",
+	"si", "mi", "ti", "ci", "vi",
+)
+
+func set(items ...string) map[string]struct{} {
+	set := make(map[string]struct{}, len(items))
+	for _, item := range items {
+		set[item] = struct{}{}
+	}
+	return set
+}
+
+func phrase(fillers map[string]struct{}, text string) []Token {
+	tokens := []Token{}
+	for _, token := range Tokenize(text) {
+		token.Text = strings.ToLower(strings.ReplaceAll(token.Text, "’", "'"))
+		if _, exists := fillers[token.Text]; !exists {
+			tokens = append(tokens, token)
+		}
+	}
+	return tokens
+}
+
+// The words of text that tell a phrase apart, lowercased, without articles and reflexive pronouns.
+func GermanPhrase(text string) []Token {
+	return phrase(fuellwoerter, text)
+}
+
+func FrenchPhrase(text string) []Token {
+	return phrase(mots, text)
+}
+
+func ItalianPhrase(text string) []Token {
+	return phrase(riempitivi, text)
+}
+
+// Prefixes of German verbs that move to the end of a main clause: aufhören, ich höre auf.
+var trennbarePraefixe = []string{
+	"zusammen", "zurück", "weiter", "fest", "fort", "nach", "aus", "auf", "ein", "her", "hin", "los",
+	"mit", "vor", "weg", "ab", "an", "bei", "zu",
+}
+
+// Split a separable German verb into its prefix and the rest of it.
+func SplitGermanSeparableVerb(verb string) (string, string, bool) {
+	for _, prefix := range trennbarePraefixe {
+		stem, found := strings.CutPrefix(verb, prefix)
+		if found && len([]rune(stem)) >= 3 {
+			return prefix, stem, true
+		}
+	}
+	return "", verb, false
+}
@@ -0,0 +1,55 @@
+package languages
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTokenize(t *testing.T) {
+	tokens := Tokenize("Devi fare l'acqua, porte-monnaie!")
+
+	texts := []string{}
+	for _, token := range tokens {
+		texts = append(texts, token.Text)
+	}
+	if got := strings.Join(texts, "|"); got != "Devi|fare|l'|acqua|porte-monnaie" {
+		t.Errorf("Tokenize = %q", got)
+	}
+	if tokens[3].Start != 12 || tokens[3].End != 17 {
+		t.Errorf("acqua is at %d-%d; want 12-17", tokens[3].Start, tokens[3].End)
+	}
+}
+
+func TestPhrase(t *testing.T) {
+	tests := []struct {
+		phrase func(string) []Token
+		in     string
+		want   string
+	}{
+		{GermanPhrase, "sich Sorgen machen", "sorgen machen"},
+		{GermanPhrase, "die Nase voll haben", "nase voll haben"},
+		{ItalianPhrase, "fare finta di", "fare finta di"},
+		{ItalianPhrase, "farsi la doccia", "farsi doccia"},
+		{FrenchPhrase, "s’inquiéter de", "inquiéter de"},
+	}
+
+	for _, tt := range tests {
+		texts := []string{}
+		for _, token := range tt.phrase(tt.in) {
+			texts = append(texts, token.Text)
+		}
+		if got := strings.Join(texts, " "); got != tt.want {
+			t.Errorf("phrase of %q = %q; want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestSplitGermanSeparableVerb(t *testing.T) {
+	prefix, stem, ok := SplitGermanSeparableVerb("aufhören")
+	if !ok || prefix != "auf" || stem != "hören" {
+		t.Errorf("SplitGermanSeparableVerb(aufhören) = %q, %q, %v", prefix, stem, ok)
+	}
+	if _, _, ok := SplitGermanSeparableVerb("machen"); ok {
+		t.Errorf("machen is not separable")
+	}
+}
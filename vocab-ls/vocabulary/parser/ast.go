@@ -15,6 +15,32 @@ const (
 	Français     Language = "Franzözisch"
 )
 
+// The specifier the language is written with, as in `> (it)`.
+func (l Language) Code() string {
+	switch l {
+	case Italiano:
+		return "it"
+	case Deutsch:
+		return "de"
+	case Français:
+		return "fr"
+	}
+	return ""
+}
+
+// The language written with code, Unrecognized if there is none.
+func LanguageOf(code string) Language {
+	for _, lang := range []Language{Italiano, Deutsch, Français} {
+		if lang.Code() == code {
+			return lang
+		}
+	}
+	return Unrecognized
+}
+
+// The grade of a direction left out of a two-way grade, as in word(/3).
+const Ungraded = -1
+
 type Word struct {
 	Line int
 	// Text represent the actual string value of a word with or without its article.
@@ -29,8 +55,16 @@ type Word struct {
 	// "hello" end = 5
 	// "ö" end non ascii chars like ö is treated as 1 not 2
 	End int
-	// grade parsed after word -> word(5)
-	Grade  int
+	// grade parsed after word -> word(5), or the grade of recognizing it -> word(5/3)
+	Grade int
+	// Graded in both directions -> word(5/3). Either side can be left out -> word(/3), then it is
+	// Ungraded.
+	TwoWay bool
+	// grade of producing the word from its meaning -> word(5/3)
+	ProductionGrade int
+	// What the word means, written after it -> unbestimmt = undetermined, or unbestimmt :: undetermined.
+	// Not part of Text, so it has no say in scheduling.
+	Gloss  string
 	Parent *WordsSection
 }
 
@@ -59,9 +93,12 @@ func (u *UtteranceSection) Identity() string {
 }
 
 type DateSection struct {
-	Line   int
-	Text   string
-	Time   time.Time
+	Line int
+	Text string
+	// The day, and the time of day if the header has one
+	Time time.Time
+	// Whether the header has a time of day, as in 20/05/2025 18:30
+	Timed  bool
 	Start  int
 	End    int
 	Parent *VocabularySection
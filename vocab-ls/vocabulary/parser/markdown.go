@@ -0,0 +1,69 @@
+package parser
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	// # 04/09/2025 or ## Mon, 04/09/2025, at any heading level
+	markdownDateHeading = regexp.MustCompile(`^ {0,3}#{1,6}[ \t]+(\p{L}+[.,]?[ \t]+)?\d[^ \t]*[ \t]*$`)
+	markdownHeading     = regexp.MustCompile(`^ {0,3}#{1,6}([ \t]|$)`)
+	markdownFence       = regexp.MustCompile("^ {0,3}(`{3,}|~{3,})[ \t]*([^ \t`]*)")
+)
+
+// Whether the document at uri is Markdown, with vocab only in some parts of it.
+func IsMarkdown(uri string) bool {
+	lower := strings.ToLower(uri)
+	return strings.HasSuffix(lower, ".md") || strings.HasSuffix(lower, ".markdown")
+}
+
+// Blank out everything of a Markdown document that isn't vocab, so the scanner only sees what is
+// inside ```vocab fences and under date headings.
+//
+// Every line stays where it is and every kept character keeps its column, so positions found in
+// the masked text are positions in the document.
+func MaskMarkdown(text string) string {
+	var masked strings.Builder
+	masked.Grow(len(text))
+
+	// the fence a block was opened with, "" outside of fences
+	fence := ""
+	vocabFence := false
+	underDateHeading := false
+
+	for line := range strings.Lines(text) {
+		content := strings.TrimRight(line, "\r\n")
+		lineBreak := line[len(content):]
+		keep := false
+
+		switch {
+		case fence != "":
+			if closing := markdownFence.FindStringSubmatch(content); closing != nil &&
+				strings.HasPrefix(closing[1], fence) && strings.TrimSpace(content) == closing[1] {
+				fence = ""
+				vocabFence = false
+			} else {
+				keep = vocabFence
+			}
+		case markdownFence.MatchString(content):
+			opening := markdownFence.FindStringSubmatch(content)
+			fence = opening[1]
+			vocabFence = opening[2] == "vocab"
+		case markdownDateHeading.MatchString(content):
+			// the scanner reads the date past the #
+			underDateHeading = true
+			keep = true
+		case markdownHeading.MatchString(content):
+			underDateHeading = false
+		default:
+			keep = underDateHeading
+		}
+
+		if keep {
+			masked.WriteString(content)
+		}
+		masked.WriteString(lineBreak)
+	}
+	return masked.String()
+}
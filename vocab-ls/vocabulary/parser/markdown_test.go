@@ -0,0 +1,64 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+	test "vocab/vocab_testing"
+)
+
+func TestMaskMarkdown_ShouldKeepLinesAndColumns(t *testing.T) {
+	text := strings.Join([]string{
+		"# Journal",
+		"> a quote, not vocab",
+		"## 04/09/2025",
+		"> (de) schön",
+		"Das ist **schön**.",
+		"```python",
+		"> (it) no",
+		"```",
+		"after the code block",
+		"# Notes",
+		"> (it) still no",
+		"```vocab",
+		"05/09/2025",
+		">> (de) schön(4)",
+		"```",
+		"",
+	}, "\n")
+
+	masked := strings.Split(MaskMarkdown(text), "\n")
+	test.Expect(t, 16, len(masked))
+	test.Expect(t, "", masked[0])
+	test.Expect(t, "", masked[1])
+	test.Expect(t, "## 04/09/2025", masked[2])
+	test.Expect(t, "> (de) schön", masked[3])
+	test.Expect(t, "Das ist **schön**.", masked[4])
+	test.Expect(t, "", masked[6])
+	test.Expect(t, "after the code block", masked[8])
+	test.Expect(t, "", masked[10])
+	test.Expect(t, "", masked[11])
+	test.Expect(t, "05/09/2025", masked[12])
+	test.Expect(t, ">> (de) schön(4)", masked[13])
+	test.Expect(t, "", masked[14])
+}
+
+func TestMaskMarkdown_ShouldParseWithoutDiagnosticsOutsideVocab(t *testing.T) {
+	text := "# Journal\nSome (thoughts), > and 12/12/2012 things.\n\n# 04/09/2025\n> (it) `inoltre`\nInoltre, sì.\r\n"
+	parser := NewParser(t.Context(), "file:///journal.md", NewScanner(MaskMarkdown(text)), func(any) {})
+	parser.Parse()
+
+	test.Expect(t, 1, len(parser.Ast.Sections))
+	section := parser.Ast.Sections[0]
+	test.Expect(t, 0, len(section.Diagnostics))
+	test.Expect(t, 3, section.Date.Line)
+	test.Expect(t, 2, section.Date.Start)
+	word := section.NewWords[0].Words[0]
+	test.Expect(t, "inoltre", word.Text)
+	test.Expect(t, 4, word.Line)
+	test.Expect(t, 1, len(section.Utterance))
+}
+
+func TestIsMarkdown(t *testing.T) {
+	test.Expect(t, true, IsMarkdown("file:///vault/Journal.MD"))
+	test.Expect(t, false, IsMarkdown("file:///vault/journal.vocab"))
+}
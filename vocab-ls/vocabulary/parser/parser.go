@@ -2,6 +2,7 @@ package parser
 
 import (
 	"context"
+	"regexp"
 	"strconv"
 	"strings"
 	"time"
@@ -20,6 +21,16 @@ const (
 	UnexpectedToken          string = "Unexpected Token"
 	InvalidScore             string = "Score must be a number"
 	DuplicateToken           string = "Duplicate token in same section"
+	MalformedTime            string = "Malformed time -- expected hh:mm"
+	ExpectGlossWord          string = "Expect a word before its gloss"
+	ExpectGloss              string = "Expect a gloss after = or ::"
+	AmbiguousDate            string = "Ambiguous date, read as dd/mm/yyyy. Write a day above 12 in any date of this file, or set dateFormat, to settle it"
+)
+
+var (
+	slashedDate = regexp.MustCompile(`\b(\d{2})/(\d{2})/\d{4}\b`)
+	// How a layout is written in messages and settings
+	layoutNames = strings.NewReplacer("2006", "yyyy", "01", "mm", "02", "dd")
 )
 
 type Parser struct {
@@ -34,6 +45,13 @@ type Parser struct {
 	tokenEnd   int // end pos on line
 	line       int // line, 0-indexed
 
+	// go layout of date expressions, or syntax.AutoDateLayout
+	dateLayout string
+	// Layout of slashed dates when dateLayout is auto, and whether nothing in the document tells
+	// day and month apart
+	slashedLayout    string
+	slashedAmbiguous bool
+
 	printCallback func(any)
 }
 
@@ -49,12 +67,23 @@ func NewParser(ctx context.Context, uri string, scanner *Scanner, printCallback
 		tokenStart: -1,
 		tokenEnd:   -1,
 
+		dateLayout: syntax.DateLayout,
+
 		printCallback: printCallback,
 	}
 }
 
+// Read date expressions with layout instead of the default dd/mm/yyyy.
+func (p *Parser) SetDateLayout(layout string) *Parser {
+	p.dateLayout = layout
+	return p
+}
+
 func (p *Parser) Parse() *Parser {
 	p.Ast.Sections = []*VocabularySection{}
+	if p.dateLayout == syntax.AutoDateLayout {
+		p.slashedLayout, p.slashedAmbiguous = inferSlashedLayout(p.scanner.text)
+	}
 
 	var lastSection *VocabularySection = nil
 
@@ -104,27 +133,71 @@ func (p *Parser) Parse() *Parser {
 }
 
 func (p *Parser) parseDateExpression() {
-	parsed, err := time.Parse(syntax.DateLayout, p.text)
-	parsedAsLocalTime := time.Date(parsed.Year(), parsed.Month(), parsed.Day(), 0, 0, 0, 0, time.Local)
+	layout := p.dateLayoutOf(p.text)
+	// a calendar day, the same wherever and whenever the file is read
+	parsed, err := time.Parse(layout, p.text)
 	section := p.currentVocabSection()
 	date := &DateSection{
 		Parent: section,
 		Text:   p.text,
-		Time:   parsedAsLocalTime,
+		Time:   parsed,
 		Start:  p.tokenStart,
 		End:    p.tokenEnd, Line: p.line,
 	}
 	section.Date = date
 
 	if err != nil {
-		p.errorHere(&err, MalformedDate)
+		p.errorHere(&err, "Malformed date -- expected "+layoutNames.Replace(layout))
 		return
 	}
+	if layout == p.slashedLayout && p.slashedAmbiguous && parsed.Day() != int(parsed.Month()) {
+		p.diagnosticsAt(nil, AmbiguousDate, p.tokenStart, p.tokenEnd, lsproto.DiagnosticsSeverityWarning)
+	}
+
+	if token, _ := p.peekTokenNotWhitespace(); token == TokenTimeExpression {
+		p.nextTokenNotWhitespace()
+		clock, err := time.Parse("15:04", p.text)
+		if err != nil {
+			p.errorHere(&err, MalformedTime)
+			return
+		}
+		date.Time = date.Time.Add(time.Duration(clock.Hour())*time.Hour + time.Duration(clock.Minute())*time.Minute)
+		date.Timed = true
+	}
 
 	p.nextToken()
 
 }
 
+// The layout to read date with.
+func (p *Parser) dateLayoutOf(date string) string {
+	if p.dateLayout != syntax.AutoDateLayout {
+		return p.dateLayout
+	}
+	switch {
+	case strings.Contains(date, "-"):
+		return "2006-01-02"
+	case strings.Contains(date, "."):
+		return "02.01.2006"
+	}
+	return p.slashedLayout
+}
+
+// Day first unless a date of text can only be month first. Ambiguous if no date has a day above 12.
+func inferSlashedLayout(text string) (string, bool) {
+	dayFirst, monthFirst := false, false
+	for _, match := range slashedDate.FindAllStringSubmatch(text, -1) {
+		first, _ := strconv.Atoi(match[1])
+		second, _ := strconv.Atoi(match[2])
+		dayFirst = dayFirst || first > 12
+		monthFirst = monthFirst || second > 12
+	}
+	if monthFirst && !dayFirst {
+		return "01/02/2006", false
+	}
+	return syntax.DateLayout, !dayFirst && !monthFirst
+}
+
 func (p *Parser) parseVocabSection() {
 	currentSection := p.currentVocabSection()
 	words := &WordsSection{
@@ -145,16 +218,9 @@ func (p *Parser) parseVocabSection() {
 		p.errorHere(nil, ExpectLanguageExpression)
 		return
 	}
-	switch p.text {
-	case "it":
-		words.Language = Italiano
-	case "de":
-		words.Language = Deutsch
-	case "fr":
-		words.Language = Français
-	default:
+	words.Language = LanguageOf(p.text)
+	if words.Language == Unrecognized {
 		p.errorHere(nil, UnrecognizedLanguage)
-		words.Language = Unrecognized
 	}
 
 	p.nextTokenNotWhitespace()
@@ -226,7 +292,7 @@ func (p *Parser) parseVocabSection() {
 			}
 			return
 		case TokenSemanticSpecifierLiteral:
-			number, err := strconv.Atoi(p.text)
+			recognition, production, twoWay, err := parseGrade(p.text)
 			if err != nil {
 				p.errorHere(nil, InvalidScore)
 				for {
@@ -241,7 +307,10 @@ func (p *Parser) parseVocabSection() {
 			}
 			// if after parsing, length is still 0, don't assign to grade
 			if len(words.Words) > 0 {
-				words.Words[len(words.Words)-1].Grade = number
+				word := words.Words[len(words.Words)-1]
+				word.Grade = recognition
+				word.TwoWay = twoWay
+				word.ProductionGrade = production
 			}
 			p.nextTokenNotWhitespace()
 		case TokenWordLiteral:
@@ -257,6 +326,21 @@ func (p *Parser) parseVocabSection() {
 
 			newWordFromText(parsing)
 			p.nextTokenNotWhitespace()
+		case TokenGloss:
+			if parsing != "" {
+				newWordFromText(parsing)
+			}
+			glossStart := p.tokenStart
+			gloss := p.parseGloss()
+			if len(words.Words) == 0 {
+				p.diagnosticsAt(nil, ExpectGlossWord, glossStart, p.tokenStart, lsproto.DiagnosticsSeverityError)
+				continue
+			}
+			if gloss == "" {
+				p.diagnosticsAt(nil, ExpectGloss, glossStart, p.tokenStart, lsproto.DiagnosticsSeverityError)
+				continue
+			}
+			words.Words[len(words.Words)-1].Gloss = gloss
 		default:
 			if parsingStart == -1 {
 				// remember the start position of text
@@ -270,6 +354,53 @@ func (p *Parser) parseVocabSection() {
 	}
 }
 
+// Read a grade, 4, or the grades of recognizing and producing a word, 4/2. One of the two can be
+// left out, /2, but not both.
+func parseGrade(text string) (recognition int, production int, twoWay bool, err error) {
+	before, after, twoWay := strings.Cut(text, "/")
+	if !twoWay {
+		recognition, err = strconv.Atoi(text)
+		return recognition, recognition, false, err
+	}
+	if strings.TrimSpace(before) == "" && strings.TrimSpace(after) == "" {
+		return 0, 0, true, strconv.ErrSyntax
+	}
+
+	grade := func(side string) (int, error) {
+		if strings.TrimSpace(side) == "" {
+			return Ungraded, nil
+		}
+		return strconv.Atoi(strings.TrimSpace(side))
+	}
+	if recognition, err = grade(before); err != nil {
+		return 0, 0, true, err
+	}
+	production, err = grade(after)
+	return recognition, production, true, err
+}
+
+// Read the gloss after = or :: up to the end of the entry: a comma, the end of the line, or the
+// grade of the word.
+func (p *Parser) parseGloss() string {
+	var sb strings.Builder
+	p.nextTokenNotWhitespace()
+	for {
+		switch p.token {
+		case TokenComma, TokenLineBreak, TokenEOF, TokenCommentTrivia:
+			return strings.TrimSpace(sb.String())
+		case TokenSemanticSpecifierLiteral:
+			if _, _, _, err := parseGrade(p.text); err == nil {
+				return strings.TrimSpace(sb.String())
+			}
+			// house (building)
+			sb.WriteString("(" + p.text + ")")
+		default:
+			sb.WriteString(p.text)
+		}
+		p.nextToken()
+	}
+}
+
 func (p *Parser) parseUtteranceSection() {
 	var sb strings.Builder
 
@@ -342,6 +473,18 @@ func (p *Parser) nextTokenNotWhitespace() {
 	}
 }
 
+// The next token that isn't whitespace, without moving past anything.
+func (p *Parser) peekTokenNotWhitespace() (Token, string) {
+	scanner := *p.scanner
+	defer func() { *p.scanner = scanner }()
+	for {
+		token, text := p.scanner.Scan()
+		if token != TokenWhitespace {
+			return token, text
+		}
+	}
+}
+
 func (p *Parser) currentVocabSection() *VocabularySection {
 	sectionCount := len(p.Ast.Sections)
 	if sectionCount == 0 {
@@ -5,6 +5,7 @@ import (
 	"testing"
 	"time"
 	lsproto "vocab/lsp"
+	"vocab/syntax"
 	test "vocab/vocab_testing"
 )
 
@@ -20,19 +21,19 @@ func TestOnlyDateSection(t *testing.T) {
 	expectations := []Expectation{
 		{
 			Input:      "20/08/2025",
-			ParsedDate: time.Date(2025, time.August, 20, 0, 0, 0, 0, time.Local),
+			ParsedDate: time.Date(2025, time.August, 20, 0, 0, 0, 0, time.UTC),
 			Start:      0,
 			End:        10,
 		},
 		{
 			Input:      " 20/08/2025 ",
-			ParsedDate: time.Date(2025, time.August, 20, 0, 0, 0, 0, time.Local),
+			ParsedDate: time.Date(2025, time.August, 20, 0, 0, 0, 0, time.UTC),
 			Start:      1,
 			End:        11,
 		},
 		{
 			Input:      "00/00/0000",
-			ParsedDate: time.Date(1, 1, 1, 0, 0, 0, 0, time.Local),
+			ParsedDate: time.Date(1, 1, 1, 0, 0, 0, 0, time.UTC),
 			Start:      0,
 			End:        10,
 			Error:      MalformedDate,
@@ -100,7 +101,7 @@ func TestSingleWordSection(t *testing.T) {
 	test.Expect(t, 0, len(parser.currentVocabSection().Diagnostics))
 
 	section := parser.Ast.Sections[0]
-	test.Expect(t, time.Date(2025, time.August, 20, 0, 0, 0, 0, time.Local), section.Date.Time)
+	test.Expect(t, time.Date(2025, time.August, 20, 0, 0, 0, 0, time.UTC), section.Date.Time)
 	test.Expect(t, 0, section.Date.Line)
 	test.Expect(t, 0, section.Date.Start)
 	test.Expect(t, 10, section.Date.End)
@@ -273,7 +274,7 @@ func TestFullSectionParsing(t *testing.T) {
 
 	// ======== SECTION 1: 02/10/2025 ========
 	section1 := parser.Ast.Sections[0]
-	test.Expect(t, time.Date(2025, time.October, 2, 0, 0, 0, 0, time.Local), section1.Date.Time)
+	test.Expect(t, time.Date(2025, time.October, 2, 0, 0, 0, 0, time.UTC), section1.Date.Time)
 
 	// Reviewed words (>>)
 	test.Expect(t, 1, len(section1.ReviewedWords))
@@ -301,7 +302,7 @@ func TestFullSectionParsing(t *testing.T) {
 
 	// ======== SECTION 2: 03/10/2025 ========
 	section2 := parser.Ast.Sections[1]
-	test.Expect(t, time.Date(2025, time.October, 3, 0, 0, 0, 0, time.Local), section2.Date.Time)
+	test.Expect(t, time.Date(2025, time.October, 3, 0, 0, 0, 0, time.UTC), section2.Date.Time)
 	test.Expect(t, 12, section2.Date.Line)
 
 	// New words (>)
@@ -464,3 +465,148 @@ func TestMissingWordWithScoreSpecifierShouldNotThrowError(t *testing.T) {
 	text := "16/10/2025 \n> (it) (2)"
 	NewParser(t.Context(), "xxx", NewScanner(text), func(a any) {}).Parse()
 }
+
+func TestDateSection_ShouldRecognizeEveryShapeAndPrefix(t *testing.T) {
+	type Expectation struct {
+		Input  string
+		Layout string
+		Start  int
+	}
+	expectations := []Expectation{
+		{Input: "2025-05-20", Layout: "2006-01-02", Start: 0},
+		{Input: "20.05.2025", Layout: "02.01.2006", Start: 0},
+		{Input: "# 20/05/2025", Layout: syntax.DateLayout, Start: 2},
+		{Input: "Mon, 20/05/2025", Layout: syntax.DateLayout, Start: 5},
+		{Input: "## Dienstag 20.05.2025", Layout: syntax.AutoDateLayout, Start: 12},
+		{Input: "  lunedì 2025-05-20", Layout: syntax.AutoDateLayout, Start: 10},
+	}
+
+	for _, expectation := range expectations {
+		parser := NewParser(t.Context(), "xxx", NewScanner(expectation.Input+"\n> (it) casa"), func(a any) {}).SetDateLayout(expectation.Layout)
+		parser.Parse()
+
+		section := parser.Ast.Sections[0]
+		test.Expect(t, 0, len(section.Diagnostics))
+		test.Expect(t, time.Date(2025, time.May, 20, 0, 0, 0, 0, time.UTC), section.Date.Time)
+		test.Expect(t, expectation.Start, section.Date.Start)
+	}
+}
+
+func TestDateSection_ShouldNotTakeEveryNumberForADate(t *testing.T) {
+	parser := NewParser(t.Context(), "xxx", NewScanner("20/05/2025\n> (it) casa\nAlle 12.30 o il 3-4 maggio, Friday 20/05/2025."), func(a any) {})
+	parser.Parse()
+
+	test.Expect(t, 1, len(parser.Ast.Sections))
+	test.Expect(t, 0, len(parser.Ast.Sections[0].Diagnostics))
+	test.Expect(t, "Alle 12.30 o il 3-4 maggio, Friday 20/05/2025.", parser.Ast.Sections[0].Utterance[0].Text)
+}
+
+func TestDateSection_ShouldExpectTheConfiguredLayout(t *testing.T) {
+	parser := NewParser(t.Context(), "xxx", NewScanner("2025-05-20"), func(a any) {}).SetDateLayout("02.01.2006")
+	parser.Parse()
+
+	test.Expect(t, 1, len(parser.Ast.Sections[0].Diagnostics))
+	test.Expect(t, "Malformed date -- expected dd.mm.yyyy", parser.Ast.Sections[0].Diagnostics[0].Message)
+}
+
+func TestDateSection_AutoShouldInferDayAndMonthOrder(t *testing.T) {
+	parse := func(text string) *Parser {
+		parser := NewParser(t.Context(), "xxx", NewScanner(text), func(a any) {}).SetDateLayout(syntax.AutoDateLayout)
+		return parser.Parse()
+	}
+
+	// 05/20 can only be month first
+	parser := parse("04/05/2025\n> (it) casa\n05/20/2025\n> (it) cane")
+	test.Expect(t, time.Date(2025, time.April, 5, 0, 0, 0, 0, time.UTC), parser.Ast.Sections[0].Date.Time)
+	test.Expect(t, 0, len(parser.Ast.Sections[0].Diagnostics))
+
+	parser = parse("04/05/2025\n> (it) casa\n20/05/2025\n> (it) cane")
+	test.Expect(t, time.Date(2025, time.May, 4, 0, 0, 0, 0, time.UTC), parser.Ast.Sections[0].Date.Time)
+	test.Expect(t, 0, len(parser.Ast.Sections[0].Diagnostics))
+
+	// nothing tells, read as day first but said so
+	parser = parse("04/05/2025\n> (it) casa\n01/01/2025\n> (it) cane\n2025-05-20\n> (it) gatto")
+	test.Expect(t, time.Date(2025, time.May, 4, 0, 0, 0, 0, time.UTC), parser.Ast.Sections[0].Date.Time)
+	test.Expect(t, 1, len(parser.Ast.Sections[0].Diagnostics))
+	test.Expect(t, AmbiguousDate, parser.Ast.Sections[0].Diagnostics[0].Message)
+	test.Expect(t, lsproto.DiagnosticsSeverityWarning, parser.Ast.Sections[0].Diagnostics[0].Severity)
+	// the same either way
+	test.Expect(t, 0, len(parser.Ast.Sections[1].Diagnostics))
+	test.Expect(t, 0, len(parser.Ast.Sections[2].Diagnostics))
+}
+
+func TestDateSection_ShouldTakeAnOptionalTimeOfDay(t *testing.T) {
+	parser := NewParser(t.Context(), "xxx", NewScanner("20/05/2025 18:30\n> (it) casa\n21/05/2025 9:05\n> (it) cane\n22/05/2025\n> (it) gatto"), func(a any) {})
+	parser.Parse()
+
+	test.Expect(t, 3, len(parser.Ast.Sections))
+	test.Expect(t, time.Date(2025, time.May, 20, 18, 30, 0, 0, time.UTC), parser.Ast.Sections[0].Date.Time)
+	test.Expect(t, true, parser.Ast.Sections[0].Date.Timed)
+	test.Expect(t, time.Date(2025, time.May, 21, 9, 5, 0, 0, time.UTC), parser.Ast.Sections[1].Date.Time)
+	test.Expect(t, false, parser.Ast.Sections[2].Date.Timed)
+	for _, section := range parser.Ast.Sections {
+		test.Expect(t, 0, len(section.Diagnostics))
+	}
+}
+
+func TestDateSection_MalformedTime(t *testing.T) {
+	parser := NewParser(t.Context(), "xxx", NewScanner("20/05/2025 25:30\n> (it) casa"), func(a any) {})
+	parser.Parse()
+
+	test.Expect(t, 1, len(parser.Ast.Sections[0].Diagnostics))
+	test.Expect(t, MalformedTime, parser.Ast.Sections[0].Diagnostics[0].Message)
+}
+
+func TestGloss_ShouldBeKeptApartFromTheWord(t *testing.T) {
+	text := "20/05/2025\n> (de) unbestimmt = undetermined, der Hund :: dog(4), Haus(3) = house (building), `a=b`\nein Hund = a dog"
+	parser := NewParser(t.Context(), "xxx", NewScanner(text), func(a any) {})
+	parser.Parse()
+
+	section := parser.Ast.Sections[0]
+	test.Expect(t, 0, len(section.Diagnostics))
+	words := section.NewWords[0].Words
+	test.Expect(t, 4, len(words))
+	test.Expect(t, "unbestimmt", words[0].Text)
+	test.Expect(t, "undetermined", words[0].Gloss)
+	test.Expect(t, 17, words[0].End)
+	test.Expect(t, "der Hund", words[1].Text)
+	test.Expect(t, "dog", words[1].Gloss)
+	test.Expect(t, 4, words[1].Grade)
+	test.Expect(t, "house (building)", words[2].Gloss)
+	test.Expect(t, 3, words[2].Grade)
+	test.Expect(t, "a=b", words[3].Text)
+	test.Expect(t, "", words[3].Gloss)
+	// utterances are left as they are
+	test.Expect(t, "ein Hund = a dog", section.Utterance[0].Text)
+}
+
+func TestGloss_ShouldHaveAWordAndAGloss(t *testing.T) {
+	parser := NewParser(t.Context(), "xxx", NewScanner("20/05/2025\n> (de) = undetermined, Hund ::"), func(a any) {})
+	parser.Parse()
+
+	diagnostics := parser.Ast.Sections[0].Diagnostics
+	test.Expect(t, 2, len(diagnostics))
+	test.Expect(t, ExpectGlossWord, diagnostics[0].Message)
+	test.Expect(t, ExpectGloss, diagnostics[1].Message)
+}
+
+func TestGrade_ShouldTakeRecognitionAndProduction(t *testing.T) {
+	text := "20/05/2025\n>> (de) der Berg(4/2), Haus(/3), Hund = dog(5/), Katze(4), Maus(/)"
+	parser := NewParser(t.Context(), "xxx", NewScanner(text), func(a any) {})
+	parser.Parse()
+
+	section := parser.Ast.Sections[0]
+	test.Expect(t, 1, len(section.Diagnostics))
+	test.Expect(t, InvalidScore, section.Diagnostics[0].Message)
+	words := section.ReviewedWords[0].Words
+	test.Expect(t, 4, len(words))
+	test.Expect(t, true, words[0].TwoWay, words[1].TwoWay, words[2].TwoWay)
+	test.Expect(t, 4, words[0].Grade)
+	test.Expect(t, 2, words[0].ProductionGrade)
+	test.Expect(t, Ungraded, words[1].Grade, words[2].ProductionGrade)
+	test.Expect(t, 3, words[1].ProductionGrade)
+	test.Expect(t, "dog", words[2].Gloss)
+	// a single grade counts for both directions
+	test.Expect(t, false, words[3].TwoWay)
+	test.Expect(t, 4, words[3].Grade, words[3].ProductionGrade)
+}
@@ -1,6 +1,7 @@
 package parser
 
 import (
+	"strings"
 	"unicode/utf8"
 	"vocab/lib"
 	"vocab/syntax"
@@ -17,6 +18,8 @@ type Scanner struct {
 	// `pos` at which the current token begins
 	tokenLineOffsetStart int
 	line                 int
+	// Whether anything but whitespace was scanned on this line
+	lineStarted bool
 }
 
 func NewScanner(text string) *Scanner {
@@ -43,6 +46,17 @@ func (s *Scanner) Scan() (Token, string) {
 		return TokenEOF, ""
 	}
 
+	// `# Mon, 20/05/2025` is read as the date alone
+	if !s.lineStarted {
+		if prefix := s.datePrefixLength(); prefix > 0 {
+			s.forwardPos(prefix)
+			return TokenWhitespace, " "
+		}
+	}
+	if !lib.IsLineBreak(scanned) {
+		s.lineStarted = true
+	}
+
 	if lib.IsRecognizedLetter(scanned) {
 		c, cSize := s.charAt(0)
 		collected := string(c)
@@ -70,23 +84,17 @@ func (s *Scanner) Scan() (Token, string) {
 	}
 
 	if lib.IsDigit(scanned) {
-		collected := string(scanned)
-		s.forwardPos(1)
-		for i := 1; i < syntax.DateLength; i++ {
-			c, _ := s.charAt(0)
-			if lib.IsLineBreak(c) || s.atEnd() {
-				return TokenText, collected
-			}
-
-			if !lib.IsDigit(c) {
-				if lib.Slash != c && (i != syntax.DateSlashFirstPosition && i != syntax.DateSlashSecondPostion) {
-					return TokenText, collected
-				}
-			}
-			collected += string(c)
-			s.forwardPos(1)
+		collected := s.dateLikeRun(0)
+		s.forwardPos(len(collected))
+		s.lineStarted = true
+		if syntax.DateExpression.MatchString(collected) {
+			return TokenDateExpression, collected
+		}
+		if clock := s.timeAfter(collected); clock != "" {
+			s.forwardPos(len(clock) - len(collected))
+			return TokenTimeExpression, clock
 		}
-		return TokenDateExpression, collected
+		return TokenText, collected
 	}
 
 	// It should not be possible to overscan!
@@ -99,6 +107,19 @@ func (s *Scanner) Scan() (Token, string) {
 		s.forwardPos(1)
 		return TokenComma, ","
 
+	case lib.Equals:
+		s.forwardPos(1)
+		return TokenGloss, "="
+
+	case lib.Colon:
+		next, _ := s.charAt(1)
+		if lib.Colon == next {
+			s.forwardPos(2)
+			return TokenGloss, "::"
+		}
+		s.forwardPos(1)
+		return TokenText, ":"
+
 	case lib.VerticalLine:
 		for {
 			s.forwardPos(1)
@@ -182,6 +203,79 @@ func (s *Scanner) forwardLine() {
 	s.pos++
 	s.line++
 	s.tokenLineOffsetEnd = 0
+	s.lineStarted = false
+}
+
+// The digits and date separators from offset on.
+func (s *Scanner) dateLikeRun(offset int) string {
+	end := s.pos + offset
+	for end < len(s.text) && strings.IndexByte("0123456789/.-", s.text[end]) >= 0 {
+		end++
+	}
+	return s.text[s.pos+offset : end]
+}
+
+// The hh:mm time starting with hours, "" if there is none.
+func (s *Scanner) timeAfter(hours string) string {
+	rest := s.text[s.pos:]
+	if len(hours) > 2 || len(rest) < 3 || rest[0] != ':' || !lib.IsDigit(rune(rest[1])) || !lib.IsDigit(rune(rest[2])) {
+		return ""
+	}
+	if len(rest) > 3 && lib.IsDigit(rune(rest[3])) {
+		return ""
+	}
+	return hours + rest[:3]
+}
+
+// Length of the Markdown heading marks and weekday name before a date, 0 if there is no date right
+// after them.
+func (s *Scanner) datePrefixLength() int {
+	offset := 0
+	skipWhitespace := func() bool {
+		start := offset
+		for {
+			c, size := s.charAt(offset)
+			if !lib.IsWhiteSpaceSingleLine(c) {
+				return offset > start
+			}
+			offset += size
+		}
+	}
+
+	if c, _ := s.charAt(0); c == '#' {
+		for c, _ := s.charAt(offset); c == '#'; c, _ = s.charAt(offset) {
+			offset++
+		}
+		if !skipWhitespace() {
+			return 0
+		}
+	}
+
+	var weekday strings.Builder
+	for {
+		c, size := s.charAt(offset)
+		if !lib.IsRecognizedLetter(c) {
+			break
+		}
+		weekday.WriteRune(c)
+		offset += size
+	}
+	if weekday.Len() > 0 {
+		if _, exists := syntax.Weekdays[strings.ToLower(weekday.String())]; !exists {
+			return 0
+		}
+		if c, _ := s.charAt(offset); c == ',' || c == '.' {
+			offset++
+		}
+		if !skipWhitespace() {
+			return 0
+		}
+	}
+
+	if offset == 0 || !syntax.DateExpression.MatchString(s.dateLikeRun(offset)) {
+		return 0
+	}
+	return offset
 }
 
 // Does not throw error and return -1 if index out of range
@@ -27,4 +27,7 @@ const (
 	// We need to emit whitespace here. We can't be 100% sure yet in the scanner whether we're in the
 	// example section or not. The parser knows, so all spaces need to be forwarded.
 	TokenWhitespace
+
+	TokenTimeExpression // hh:mm
+	TokenGloss          // = or ::, what follows is what the word means
 )